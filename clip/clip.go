@@ -20,19 +20,11 @@
 //	sub := p.SubCommand("serve", "Start server", "")
 //	sub.SetRuns(serveRun, nil, nil)
 //
-//	cmd, err := p.Parse(nil) // nil → os.Args
-//	if errors.Is(err, clip.ErrHelp) {
-//	    os.Exit(0)
-//	}
-//	if err != nil {
-//	    fmt.Fprintln(os.Stderr, err)
-//	    p.Close()
-//	    os.Exit(1)
-//	}
-//	os.Exit(func() int {
-//	    if err := cmd.Run(); err != nil { fmt.Fprintln(os.Stderr, err); return 1 }
-//	    return 0
-//	}())
+//	os.Exit(p.Execute(nil)) // nil → os.Args
+//
+// [Parser.Execute] parses the arguments, runs the matched command, closes the
+// parser and maps the outcome to an exit status.  Call [Parser.Parse] and
+// [Command.Run] directly to handle errors yourself.
 //
 // # Typical use — package-level convenience API (backwards-compatible)
 //
@@ -56,19 +48,26 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math/big"
 	"net"
+	"net/netip"
+	"net/url"
 	"os"
-	"strconv"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"strings"
 	"time"
 )
 
 // Sentinel errors returned by [Parser.Parse].
 var (
-	// ErrHelp is returned when the user requests help (--help / -h).
-	// The help text has already been written to stdout; callers should
-	// exit with status 0.
+	// ErrHelp is returned when the user requests help (--help / -h), or
+	// the effective configuration (--print-config).  The text has already
+	// been written to stdout; callers should exit with status 0.
 	ErrHelp = errors.New("help requested")
 
 	// ErrNotRunnable is returned by [Command.Run] when the matched command
@@ -95,14 +94,6 @@ type IOption interface {
 	Parse(s string) error
 }
 
-type optSt int
-
-const (
-	optStDefault optSt = iota
-	optStMustSet
-	optStSet
-)
-
 // Option describes a single command-line option or positional argument.
 // Callers receive a *Option from the registration functions and may chain
 // modifier methods ([Option.MustSet], [Option.Hide], etc.) on it.
@@ -114,11 +105,26 @@ type Option struct {
 	desc      string
 
 	hasArg      bool
+	optArg      bool   // argument may be omitted; see Option.Implicit
+	implicit    string // value used when an optArg argument is omitted
+	pos         bool // positional argument
+	arity       byte // positionals only; see Option.Arity
 	incrStep    int
 	reverseFlag bool
+	persistent  bool
 	hide        bool
 	repeatable  bool
-	status      optSt
+	mustSet     bool
+	secret      bool
+	env         string   // see Option.Env
+	prog        *string  // see Option.SetValue
+	choices     []string // see Option.Choices
+	suggest     string   // see Option.Suggest
+	group       string   // help section; see Option.Group
+	src         Source   // where the value came from in the last Parse
+	restore     func()   // resets v to its value before the first Parse
+	dft         string   // v.String() when restore was taken
+	dftSep      string   // list separator in dft; see Option.defaultSep
 }
 
 // Command represents a (possibly nested) command with its own set of options,
@@ -140,12 +146,22 @@ type Command struct {
 	init func(c *Command) error
 	fini func(c *Command) error
 
+	preRun  func(c *Command) error            // see Command.PersistentPreRun
+	postRun func(c *Command) error            // see Command.PersistentPostRun
+	onError func(c *Command, err error) error // see Command.OnError
+
 	hide   bool
 	parent *Command
+	plugin *plugin // set for sub-commands found by DiscoverPlugins
+
+	argsName string      // see Command.AcceptArguments
+	examples [][2]string // command line and explanation; see Command.Example
 
 	logfilePath  string
 	logfileMaxSz int64
 	logfile      *os.File
+	logOut       io.Writer        // see Parser.SetLogOutput
+	now          func() time.Time // see Parser.SetClock
 	logger       *log.Logger
 	logC         chan string
 	logDoneC     chan struct{}
@@ -162,6 +178,17 @@ type Parser struct {
 	helpOption Option
 	progInfo   string
 	logBufSize int
+	strict     bool
+	respFiles  bool
+	out        io.Writer
+	config     map[string]string
+	prompt     *prompter
+	locale     string
+
+	parseErrCode  int
+	errOut        io.Writer // see Parser.SetErrOutput
+	versionOption Option
+	version       string // see Parser.Version
 }
 
 // New returns a Parser ready to use, with the default help flag (-h/--help)
@@ -170,6 +197,10 @@ func New() *Parser {
 	return &Parser{
 		helpOption: Option{shortName: 'h', longName: "help", desc: "Help information"},
 		logBufSize: 64,
+		strict:     true,
+
+		parseErrCode:  2,
+		versionOption: Option{longName: "version", desc: "Version information"},
 	}
 }
 
@@ -195,9 +226,48 @@ func (p *Parser) SetLogBufSize(n int) *Parser {
 	return p
 }
 
+// SetStrict selects whether long options must be spelled out in full.  In
+// strict mode (the default) "--verb" only matches an option named "verb".
+// With strict set to false, any unique prefix of a long name is accepted, as
+// with GNU getopt_long: "--verb" selects "--verbose" unless another long name,
+// such as "--verbatim", also starts with it, in which case Parse reports the
+// candidates.  An exact match always wins.  Returns p so calls can be chained.
+func (p *Parser) SetStrict(strict bool) *Parser {
+	p.strict = strict
+	return p
+}
+
+// SetOutput sets where help and warnings are written; nil means os.Stdout.
+// Returns p so calls can be chained.
+func (p *Parser) SetOutput(w io.Writer) *Parser {
+	p.out = w
+	return p
+}
+
+// Output returns the writer set with SetOutput, or nil for os.Stdout.
+func (p *Parser) Output() io.Writer { return p.out }
+
+func (p *Parser) output() io.Writer {
+	if p.out == nil {
+		return os.Stdout
+	}
+	return p.out
+}
+
+// ResponseFiles enables expansion of "@file" tokens: Parse replaces each one
+// with the tokens read from the file, which may use shell-style quoting,
+// backslash escapes and # comments, and may itself contain "@file" tokens up
+// to 16 levels deep.  Tokens after a bare "--" are not expanded.  This lets
+// generated argument lists exceed the OS limit on command-line length.
+// Off by default.  Returns p so calls can be chained.
+func (p *Parser) ResponseFiles(on bool) *Parser {
+	p.respFiles = on
+	return p
+}
+
 // OpenLogfile configures log output to path with optional size-based rotation.
-// maxSize accepts a plain integer (bytes) or a number with suffix k/K, m/M, g/G.
-// Pass "" to disable rotation.  Must be called before Parse.
+// maxSize accepts a plain integer (bytes) or any size understood by
+// [ParseByteSize], e.g. "10M" or "512MiB".  Pass "" to disable rotation.  Must be called before Parse.
 func (p *Parser) OpenLogfile(path, maxSize string) error {
 	p.Command.logfilePath = path
 	var err error
@@ -205,6 +275,25 @@ func (p *Parser) OpenLogfile(path, maxSize string) error {
 	return err
 }
 
+// SetLogOutput sets where log lines go when no log file is configured with
+// OpenLogfile; nil means os.Stdout.  Must be called before Parse.  Returns p
+// so calls can be chained.
+func (p *Parser) SetLogOutput(w io.Writer) *Parser {
+	p.Command.logOut = w
+	return p
+}
+
+// LogOutput returns the writer set with SetLogOutput, or nil for os.Stdout.
+func (p *Parser) LogOutput() io.Writer { return p.Command.logOut }
+
+// SetClock sets the function that supplies log timestamps; nil means
+// time.Now.  Tests use it to make log output reproducible.  Must be called
+// before Parse.  Returns p so calls can be chained.
+func (p *Parser) SetClock(now func() time.Time) *Parser {
+	p.Command.now = now
+	return p
+}
+
 // Close shuts down the background logging goroutine and waits for it to drain.
 // [Command.Run] calls this automatically.  Call it explicitly when you exit
 // before calling Run to prevent a goroutine leak.
@@ -212,21 +301,59 @@ func (p *Parser) Close() {
 	p.Command.closeLogfile()
 }
 
-// Parse processes the argument vector and returns the matched Command.
+// Parse processes the argument vector and returns the matched Command.  It is
+// shorthand for [Parser.ParseResult] followed by [Result.Command].
 //
 // If args is nil or empty, os.Args is used.  Element [0] is always treated as
 // the program name (included in Parser.Args at index 0) and skipped during
 // option parsing.  A bare "--" token stops option processing; all subsequent
-// tokens are placed in Command.Arguments.
+// tokens are placed in Command.Arguments.  With [Parser.ResponseFiles]
+// enabled, "@file" tokens are expanded first and Parser.Args holds the result.
 //
-// If the user passes --help or -h, Parse prints help to stdout and returns
+// If the user passes --help or -h, Parse prints help to the parser's output and returns
 // (nil, ErrHelp).  Call Close if you do not subsequently call Command.Run.
+// Likewise, with [Parser.Version], --version prints the version and returns
+// (nil, ErrVersion).
+//
+// Options not given on the command line take their values from the
+// environment ([Option.Env]), the config ([Parser.SetConfig]) or
+// [Option.SetValue], in that order.  The hidden option
+// --print-config[=text|json] prints the resulting value and [Source] of every
+// option of the matched command, with secrets masked, and returns
+// (nil, ErrHelp).
 func (p *Parser) Parse(args []string) (*Command, error) {
+	r, err := p.ParseResult(args)
+	if err != nil {
+		return nil, err
+	}
+	return r.Command(), nil
+}
+
+// ParseResult is like [Parser.Parse] but returns a [Result] describing what
+// was parsed.
+//
+// Parsing can be repeated on the same Parser: every call first restores the
+// variables bound to options and positionals to the values they held before
+// the first call, and clears Command.Arguments, so nothing leaks from one
+// call into the next.  The returned Result is not affected by later calls.
+func (p *Parser) ParseResult(args []string) (*Result, error) {
+	return p.parseResult(args, true)
+}
+
+// parseResult implements ParseResult.  Empty tokens, which only an argv
+// built by a careless caller contains, are dropped if dropEmpty is set.
+func (p *Parser) parseResult(args []string, dropEmpty bool) (*Result, error) {
+	if err := p.validate(false); err != nil {
+		return nil, err
+	}
+	p.reset()
+	p.snapshotValues()
+
 	// Start the logging goroutine once per Parse/Close cycle.
 	if p.Command.logC == nil {
 		p.Command.logC = make(chan string, p.logBufSize)
 		p.Command.logDoneC = make(chan struct{})
-		go logfunc(&p.Command)
+		go p.logfunc(&p.Command)
 	}
 
 	// Append the help option exactly once even if Parse is called again on the
@@ -248,52 +375,234 @@ func (p *Parser) Parse(args []string) (*Command, error) {
 	// Reset so repeated Parse calls never accumulate stale entries.
 	p.Args = nil
 	for _, s := range args {
-		if len(s) > 0 {
+		if len(s) > 0 || !dropEmpty {
 			p.Args = append(p.Args, s)
 		}
 	}
+	if p.respFiles {
+		rest, err := expandResponseFiles(p.Args[1:])
+		if err != nil {
+			return nil, p.localize(err)
+		}
+		p.Args = append(p.Args[:1], rest...)
+	}
 
-	cmd, err := parseCommand(&p.Command, p.Args[1:], &p.helpOption)
-	if err != nil {
+	r := &Result{p: p, set: make(map[*Option]Source)}
+	if err := parseCommand(r, &p.Command, p.Args[1:]); err != nil {
 		var hr *errHelpRequest
 		if errors.As(err, &hr) {
 			p.HelpCommand(hr.cmd, hr.all)
 			return nil, ErrHelp
 		}
+		if err == ErrVersion {
+			p.printVersion()
+			return nil, ErrVersion
+		}
+		return nil, p.localize(err)
+	}
+	if r.printConfig != "" {
+		p.printConfig(r)
+		return nil, ErrHelp
+	}
+	return r, nil
+}
+
+// ParseString splits line with [SplitCommandLine] and parses the tokens as
+// the arguments that follow the program name, so "serve --port 80" selects
+// the serve sub-command.  The program name is p.Name, or the base name of
+// os.Args[0] if that is empty.  Otherwise it behaves like [Parser.Parse],
+// except that empty tokens, written as '' or "", are kept as arguments.
+func (p *Parser) ParseString(line string) (*Command, error) {
+	toks, err := SplitCommandLine(line)
+	if err != nil {
+		return nil, p.localize(err)
+	}
+	return p.parseTokens(toks)
+}
+
+// parseTokens parses the arguments toks, keeping empty ones.
+func (p *Parser) parseTokens(toks []string) (*Command, error) {
+	r, err := p.parseResult(append([]string{p.progName()}, toks...), false)
+	if err != nil {
 		return nil, err
 	}
-	return cmd, nil
+	return r.Command(), nil
+}
+
+// progName returns the name the program is invoked as.
+func (p *Parser) progName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return baseName(os.Args[0], runtime.GOOS)
+}
+
+// baseName returns the program name in path: its base name, without the
+// extension on Windows, where argv[0] is usually "tool.exe".
+func baseName(path, goos string) string {
+	b := filepath.Base(path)
+	if goos == "windows" {
+		b = strings.TrimSuffix(b, filepath.Ext(b))
+	}
+	return b
+}
+
+// shownName is like progName, but prefers the name Parse saw.
+func (p *Parser) shownName() string {
+	if p.Name == "" && len(p.Args) > 0 {
+		return baseName(p.Args[0], runtime.GOOS)
+	}
+	return p.progName()
+}
+
+// walk calls f for c and every command below it, parents first.
+func (c *Command) walk(f func(*Command)) {
+	f(c)
+	for _, sc := range c.subcmds {
+		sc.walk(f)
+	}
+}
+
+// snapshotValues records the current value of every option and positional
+// that has no snapshot yet, so reset can restore it later.
+func (p *Parser) snapshotValues() {
+	p.walk(func(c *Command) {
+		for _, o := range slices.Concat(c.opts, c.positionals) {
+			if o.restore == nil && o.v != nil {
+				o.restore = snapshot(o.v)
+				o.dft, o.dftSep = o.v.String(), valueSep(o.v)
+			}
+		}
+	})
+}
+
+// defaultString returns the text of o's value before the first Parse, so
+// help printed halfway through a Parse, as for "-n 5 --help", shows the
+// default rather than the value just parsed.
+func (o *Option) defaultString() string {
+	if o.restore != nil {
+		return o.dft
+	}
+	return o.v.String()
+}
+
+// defaultSep returns the separator between the elements of defaultString for
+// a list, which is "," unless an element contains a comma, or "" for other
+// values.
+func (o *Option) defaultSep() string {
+	if o.restore != nil {
+		return o.dftSep
+	}
+	return valueSep(o.v)
+}
+
+// reset returns the command tree to its state before the first Parse:
+// option values are restored, Arguments are cleared, and sub-commands drop
+// the log channel they borrowed during the last Run.
+func (p *Parser) reset() {
+	p.walk(func(c *Command) {
+		for _, o := range slices.Concat(c.opts, c.positionals) {
+			if o.restore != nil {
+				o.restore()
+			}
+			o.src = SourceDefault
+		}
+		c.Arguments = nil
+		if c != &p.Command && c.logDoneC == nil {
+			c.logC = nil
+		}
+	})
 }
 
-// HelpCommand prints help for c to stdout.  Pass nil to print root-level help.
+// HelpCommand prints help for c to the parser's output (stdout unless changed
+// with [Parser.SetOutput]).  Pass nil to print root-level help.
 func (p *Parser) HelpCommand(c *Command, all bool) {
+	w := p.output()
 	var lst [][2]string
 	if c == nil {
 		c = &p.Command
 	}
+	fmt.Fprintf(w, "%s: %s\n\n", p.tr("Usage"), p.synopsis(c))
 	if c == &p.Command {
-		fmt.Printf("%s\n\n", FormatText(p.progInfo, 80, 0, 0))
+		fmt.Fprintf(w, "%s\n\n", FormatText(p.progInfo, 80, 0, 0))
 	} else {
 		s := c.longDesc
 		if s == "" {
-			s = c.desc
+			s = c.description()
 		}
 		lst = append(lst, [2]string{c.Name, s})
-		if prtList(lst, "") > 0 {
-			fmt.Println()
+		if prtList(w, lst, "") > 0 {
+			fmt.Fprintln(w)
 		}
 		lst = nil
 	}
-	prtOptions(c.opts, "Options", all, &p.helpOption)
-	prtOptions(c.positionals, "Positionals", all, &p.helpOption)
+	groups := []string{""}
+	for _, o := range c.opts {
+		if !slices.Contains(groups, o.group) {
+			groups = append(groups, o.group)
+		}
+	}
+	for _, g := range groups {
+		var opts []*Option
+		for _, o := range c.opts {
+			if o.group == g {
+				opts = append(opts, o)
+			}
+		}
+		if g == "" {
+			g = p.tr("Options")
+		}
+		p.prtOptions(w, opts, g, all)
+	}
+	p.prtOptions(w, c.inheritedOpts(), p.tr("Global Options"), all)
+	p.prtOptions(w, c.positionals, p.tr("Positionals"), all)
 	for _, sc := range c.subcmds {
 		if all || !sc.hide {
-			lst = append(lst, [2]string{fmt.Sprintf("  %s", sc.Name), sc.desc})
+			lst = append(lst, [2]string{fmt.Sprintf("  %s", sc.Name), sc.description()})
+		}
+	}
+	if prtList(w, lst, p.tr("Sub-Commands")) > 0 {
+		fmt.Fprintln(w)
+	}
+	if len(c.examples) > 0 {
+		fmt.Fprintf(w, "%s:\n\n", p.tr("Examples"))
+		for _, e := range c.examples {
+			if e[1] != "" {
+				fmt.Fprintf(w, "  # %s\n", e[1])
+			}
+			fmt.Fprintf(w, "  %s\n\n", e[0])
 		}
 	}
-	if prtList(lst, "Sub-Commands") > 0 {
-		fmt.Println()
+}
+
+// synopsis returns the usage line for c, e.g.
+// "prog serve [OPTIONS] <ADDR> [ARGS...]".
+func (p *Parser) synopsis(c *Command) string {
+	var words []string
+	for pc := c; pc.parent != nil; pc = pc.parent {
+		words = append([]string{pc.Name}, words...)
+	}
+	words = append([]string{p.shownName()}, words...)
+	for _, o := range c.lookupOpts() {
+		if !o.hide && o != &p.helpOption {
+			words = append(words, "[OPTIONS]")
+			break
+		}
+	}
+	if len(c.subcmds) > 0 {
+		if c.run != nil {
+			words = append(words, "[COMMAND]")
+		} else {
+			words = append(words, "<COMMAND>")
+		}
+	}
+	for _, o := range c.positionals {
+		words = append(words, positionalName(o))
 	}
+	if c.argsName != "" {
+		words = append(words, "["+c.argsName+"...]")
+	}
+	return strings.Join(words, " ")
 }
 
 // --- Package-level convenience wrappers (all delegate to DefaultParser) ------
@@ -319,38 +628,82 @@ func SubCommand(name, desc, longDesc string) *Command {
 func SetRuns(run, init, fini func(c *Command) error) *Command {
 	return DefaultParser.SetRuns(run, init, fini)
 }
-func OpenLogfile(path, maxSize string) error  { return DefaultParser.OpenLogfile(path, maxSize) }
-func Close()                                   { DefaultParser.Close() }
-func Parse(args []string) (*Command, error)   { return DefaultParser.Parse(args) }
-func ProgDescription(desc string)              { DefaultParser.ProgDescription(desc) }
+func OpenLogfile(path, maxSize string) error    { return DefaultParser.OpenLogfile(path, maxSize) }
+func Close()                                    { DefaultParser.Close() }
+func Parse(args []string) (*Command, error)     { return DefaultParser.Parse(args) }
+func ParseString(line string) (*Command, error) { return DefaultParser.ParseString(line) }
+func ProgDescription(desc string)               { DefaultParser.ProgDescription(desc) }
+func Execute(args []string) int                 { return DefaultParser.Execute(args) }
 func SetHelpOption(shortName byte, longName string) {
 	DefaultParser.SetHelpOption(shortName, longName)
 }
-func HelpCommand(c *Command, all bool) { DefaultParser.HelpCommand(c, all) }
+func HelpCommand(c *Command, all bool)   { DefaultParser.HelpCommand(c, all) }
+func SetStrict(strict bool)              { DefaultParser.SetStrict(strict) }
+func ResponseFiles(on bool)              { DefaultParser.ResponseFiles(on) }
+func SetOutput(w io.Writer)              { DefaultParser.SetOutput(w) }
+func SetConfig(values map[string]string) { DefaultParser.SetConfig(values) }
+func SetLogOutput(w io.Writer)           { DefaultParser.SetLogOutput(w) }
+func SetClock(now func() time.Time)      { DefaultParser.SetClock(now) }
+func DiscoverPlugins(dirs ...string)     { DefaultParser.DiscoverPlugins(dirs...) }
+func SetLocale(locale string)            { DefaultParser.SetLocale(locale) }
+func SetParseErrorCode(code int)         { DefaultParser.SetParseErrorCode(code) }
+func SetErrOutput(w io.Writer)           { DefaultParser.SetErrOutput(w) }
+func Version(v string)                   { DefaultParser.Version(v) }
+func Validate() error                    { return DefaultParser.Validate() }
+func Schema() ([]byte, error)            { return DefaultParser.Schema() }
+func REPL(in io.Reader, out io.Writer) error {
+	return DefaultParser.REPL(in, out)
+}
+func EnablePrompt(in io.Reader, out io.Writer) {
+	DefaultParser.EnablePrompt(in, out)
+}
 
 // --- Command registration methods --------------------------------------------
 
-// optConv maps a typed pointer to the corresponding IOption wrapper.
+// optConv maps a typed pointer to the corresponding IOption wrapper.  Values
+// that already implement IOption (e.g. a [Value]) are used as is.
 func optConv(v interface{}) IOption {
 	switch v := v.(type) {
-	case *bool:          return (*clipBool)(v)
-	case *int:           return (*clipInt)(v)
-	case *int8:          return (*clipInt8)(v)
-	case *int16:         return (*clipInt16)(v)
-	case *int32:         return (*clipInt32)(v)
-	case *int64:         return (*clipInt64)(v)
-	case *uint:          return (*clipUint)(v)
-	case *uint8:         return (*clipUint8)(v)
-	case *uint16:        return (*clipUint16)(v)
-	case *uint32:        return (*clipUint32)(v)
-	case *uint64:        return (*clipUint64)(v)
-	case *float32:       return (*clipFloat32)(v)
-	case *float64:       return (*clipFloat64)(v)
-	case *string:        return (*clipString)(v)
-	case *time.Duration: return (*clipDura)(v)
-	case *net.IP:        return (*clipIP)(v)
+	case IOption:           return v
+	case *bool:             return (*clipBool)(v)
+	case *int:              return (*clipInt)(v)
+	case *int8:             return (*clipInt8)(v)
+	case *int16:            return (*clipInt16)(v)
+	case *int32:            return (*clipInt32)(v)
+	case *int64:            return (*clipInt64)(v)
+	case *uint:             return (*clipUint)(v)
+	case *uint8:            return (*clipUint8)(v)
+	case *uint16:           return (*clipUint16)(v)
+	case *uint32:           return (*clipUint32)(v)
+	case *uint64:           return (*clipUint64)(v)
+	case *float32:          return (*clipFloat32)(v)
+	case *float64:          return (*clipFloat64)(v)
+	case *string:           return (*clipString)(v)
+	case *time.Duration:    return (*clipDura)(v)
+	case *time.Time:        return (*clipTime)(v)
+	case *net.IP:           return (*clipIP)(v)
+	case *net.IPNet:        return (*clipIPNet)(v)
+	case *net.HardwareAddr: return (*clipMAC)(v)
+	case *netip.Prefix:     return (*clipPrefix)(v)
+	case *netip.AddrPort:   return (*clipAddrPort)(v)
+	case *url.URL:          return (*clipURL)(v)
+	case **url.URL:         return &clipURLRef{v}
+	case **regexp.Regexp:   return &clipRegexp{v}
+	case *os.FileMode:      return (*clipFileMode)(v)
+	case *big.Int:          return (*clipBigInt)(v)
+	case **big.Int:         return &clipBigIntRef{v}
+	case *big.Float:        return (*clipBigFloat)(v)
+	case **big.Float:       return &clipBigFloatRef{v}
+	case *[]string:         return NewList(v, nil)
+	case *[]int:            return NewList(v, nil)
+	case *[]int64:          return NewList(v, nil)
+	case *[]uint:           return NewList(v, nil)
+	case *[]uint64:         return NewList(v, nil)
+	case *[]float64:        return NewList(v, nil)
+	case *[]time.Duration:  return NewList(v, nil)
+	case *[]net.IP:         return NewList(v, nil)
 	default:
-		panic(fmt.Sprintf("use _Custom() for Option type %T", v))
+		panic(fmt.Sprintf("use _Custom() or clip.Arg for Option type %T", v))
 	}
 }
 
@@ -362,7 +715,8 @@ func (c *Command) Positional(v interface{}, name, desc string) *Option {
 	if len(c.subcmds) > 0 {
 		panic(fmt.Sprintf("command %s trying to add positional and sub-commands", c.Name))
 	}
-	o := &Option{v: optConv(v), longName: name, desc: desc}
+	o := &Option{v: optConv(v), longName: name, desc: desc, pos: true, arity: ArityOne}
+	_, o.secret = o.v.(*Secret)
 	c.positionals = append(c.positionals, o)
 	return o
 }
@@ -373,12 +727,33 @@ func (c *Command) PositionalCustom(v IOption, name, desc string) *Option {
 	if len(c.subcmds) > 0 {
 		panic(fmt.Sprintf("command %s trying to add positional and sub-commands", c.Name))
 	}
-	o := &Option{v: v, longName: name, desc: desc}
+	o := &Option{v: v, longName: name, desc: desc, pos: true, arity: ArityOne}
+	_, o.secret = o.v.(*Secret)
 	c.positionals = append(c.positionals, o)
 	return o
 }
 
+// lookupOpts returns the options recognised while parsing c: its own, then
+// the persistent options of each ancestor, nearest first.
+func (c *Command) lookupOpts() []*Option {
+	opts := c.opts[:len(c.opts):len(c.opts)] // appending must not touch c.opts
+	for pc := c.parent; pc != nil; pc = pc.parent {
+		for _, o := range pc.opts {
+			if o.persistent {
+				opts = append(opts, o)
+			}
+		}
+	}
+	return opts
+}
+
+// inheritedOpts returns the persistent options c inherits from its ancestors.
+func (c *Command) inheritedOpts() []*Option {
+	return c.lookupOpts()[len(c.opts):]
+}
+
 func (c *Command) appendOption(o *Option) *Option {
+	_, o.secret = o.v.(*Secret)
 	c.opts = append(c.opts, o)
 	return o
 }
@@ -397,6 +772,9 @@ func (c *Command) ArgOptionCustom(v IOption, shortName byte, longName, argName,
 	})
 }
 
+// FlagOption registers a bool flag on c.  Unless longName starts with "no-",
+// "--no-<longName>" is accepted too and stores false; help shows the pair as
+// "--[no-]<longName>".
 func (c *Command) FlagOption(v *bool, shortName byte, longName, desc string) *Option {
 	return c.appendOption(&Option{v: (*clipBool)(v), shortName: shortName, longName: longName, desc: desc})
 }
@@ -424,6 +802,30 @@ func (c *Command) SetRuns(run, init, fini func(c *Command) error) *Command {
 	return c
 }
 
+// Group puts each of opts under the heading name in c's help; see
+// [Option.Group].  Returns c so calls can be chained.
+func (c *Command) Group(name string, opts ...*Option) *Command {
+	for _, o := range opts {
+		o.Group(name)
+	}
+	return c
+}
+
+// AcceptArguments documents that c takes extra arguments, which it finds in
+// Command.Arguments; the usage line in help ends with "[name...]".  Parsing
+// is not affected.
+func (c *Command) AcceptArguments(name string) *Command {
+	c.argsName = name
+	return c
+}
+
+// Example adds an example to c's help.  cmdline is shown as given, below
+// explanation if that is not empty, in an "Examples" section.
+func (c *Command) Example(cmdline, explanation string) *Command {
+	c.examples = append(c.examples, [2]string{cmdline, explanation})
+	return c
+}
+
 // --- Option modifiers --------------------------------------------------------
 
 func (o *Option) SetIncrStep(step int) *Option {
@@ -437,6 +839,8 @@ func (o *Option) SetIncrStep(step int) *Option {
 	return o
 }
 
+// ReverseFlag makes a flag store false when given and true when given in its
+// "--no-" form.
 func (o *Option) ReverseFlag() *Option {
 	if _, ok := o.v.(*clipBool); !ok {
 		panic("ReverseFlag on non-bool Option")
@@ -445,9 +849,74 @@ func (o *Option) ReverseFlag() *Option {
 	return o
 }
 
+// Positional arities accepted by [Option.Arity].
+const (
+	ArityOne       = '1' // one token (the default)
+	ArityOptional  = '?' // zero or one token
+	ArityOneOrMore = '+' // one or more tokens
+	ArityAny       = '*' // zero or more tokens
+)
+
+// Arity sets how many tokens a positional takes.  ArityOneOrMore and ArityAny
+// positionals must be bound to a slice (e.g. *[]string, or [NewList]) and a
+// command may have at most one of them ([Parser.Validate] reports either
+// mistake), though it need not be the last: with
+// "SRC..." followed by "DST", the final token goes to DST and the rest to SRC.
+//
+// A command with such a variadic positional collects every non-option token,
+// so Command.Arguments is only filled after a bare "--".
+func (o *Option) Arity(a byte) *Option {
+	if !o.pos {
+		panic("Arity on non-positional Option")
+	}
+	if strings.IndexByte("1?+*", a) < 0 {
+		panic(fmt.Sprintf("invalid arity '%c'", a))
+	}
+	o.arity = a
+	return o
+}
+
+// Implicit makes the argument of an argument option optional, GNU style: a
+// value is taken only when attached ("--color=always", "-O2"), and v is
+// parsed instead when the option is given alone ("--color", "-O").  The next
+// token is never consumed, so "--color always" leaves "always" as a
+// positional.  Help shows the option as "--color[=WHEN]".
+func (o *Option) Implicit(v string) *Option {
+	if !o.hasArg {
+		panic("Implicit on Option without argument")
+	}
+	o.optArg, o.implicit = true, v
+	return o
+}
+
+// Persistent makes an option registered on a command also recognised after
+// any of its sub-commands, at any depth, so "tool serve -v" works when -v is
+// registered on the root.  A sub-command's own option with the same name
+// takes precedence.  Help lists inherited options under "Global Options".
+func (o *Option) Persistent() *Option {
+	if o.pos {
+		panic("Persistent on positional Option")
+	}
+	o.persistent = true
+	return o
+}
+
+// Group puts o under its own heading, name, in help instead of under
+// "Options".  Groups are shown after the ungrouped options, in the order
+// their first option was registered.
+func (o *Option) Group(name string) *Option {
+	if o.pos {
+		panic("Group on positional Option")
+	}
+	o.group = name
+	return o
+}
+
+func (o *Option) variadic() bool { return o.arity == ArityOneOrMore || o.arity == ArityAny }
+
 func (o *Option) Hide() *Option       { o.hide = true; return o }
 func (o *Option) Repeatable(r bool) *Option { o.repeatable = r; return o }
-func (o *Option) MustSet() *Option    { o.status = optStMustSet; return o }
+func (o *Option) MustSet() *Option    { o.mustSet = true; return o }
 
 // --- Logging -----------------------------------------------------------------
 
@@ -465,29 +934,39 @@ func (c *Command) closeLogfile() {
 // logfunc is the single goroutine that owns all file I/O for a Command's log
 // channel.  It opens the destination lazily on the first message, writes each
 // entry, then rotates the file after writing when the size limit is exceeded.
-func logfunc(c *Command) {
+func (p *Parser) logfunc(c *Command) {
 	for s := range c.logC {
 		// Lazily open the log destination on first message.
-		if c.logfile == nil {
+		var dst io.Writer
+		if c.logger == nil {
 			if c.logfilePath != "" {
 				var err error
 				c.logfile, err = os.OpenFile(c.logfilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 				if err != nil {
-					fmt.Printf("warn: failed to open log file '%s'\n", c.logfilePath)
+					fmt.Fprintf(p.output(), p.tr("warn: failed to open log file '%s'")+"\n", c.logfilePath)
 					c.logfile = nil
+				} else {
+					dst = c.logfile
 				}
+			} else if c.logOut != nil {
+				dst = c.logOut
 			} else {
-				c.logfile = os.Stdout
+				dst = os.Stdout
 			}
 		}
 
-		// Create the logger once we have a valid destination.
-		if c.logfile != nil && c.logger == nil {
+		// Create the logger once we have a valid destination.  With a clock
+		// set, Logf has already added the timestamp.
+		if dst != nil {
 			var prefix string
 			if len(c.Name) > 0 {
 				prefix = fmt.Sprintf("[%s] ", c.Name)
 			}
-			c.logger = log.New(c.logfile, prefix, log.LstdFlags)
+			flags := log.LstdFlags
+			if c.now != nil {
+				flags = 0
+			}
+			c.logger = log.New(dst, prefix, flags)
 		}
 
 		// Write before checking rotation so the message that crosses the
@@ -499,7 +978,7 @@ func logfunc(c *Command) {
 		}
 
 		// Rotate after writing; next message will open a fresh file.
-		if c.logfile != os.Stdout && c.logfile != nil && c.logfileMaxSz > 0 {
+		if c.logfile != nil && c.logfileMaxSz > 0 {
 			fi, err := c.logfile.Stat()
 			if err != nil || fi.Size() > c.logfileMaxSz {
 				c.logfile.Close()
@@ -510,9 +989,10 @@ func logfunc(c *Command) {
 		}
 	}
 
-	if c.logfile != os.Stdout && c.logfile != nil {
+	if c.logfile != nil {
 		c.logfile.Close()
 	}
+	c.logfile, c.logger = nil, nil
 	c.logDoneC <- struct{}{}
 }
 
@@ -520,7 +1000,17 @@ func logfunc(c *Command) {
 // The logger prefix (from c.Name) and timestamp are added automatically.
 func (c *Command) Logf(format string, v ...interface{}) {
 	if c.logC != nil {
-		c.logC <- fmt.Sprintf(format, v...)
+		s := fmt.Sprintf(format, v...)
+		root := c
+		for root.parent != nil {
+			root = root.parent
+		}
+		// Take the time now rather than when the log goroutine gets to it,
+		// so a fake clock gives each line the time it was logged at.
+		if root.now != nil {
+			s = root.now().Format("2006/01/02 15:04:05 ") + s
+		}
+		c.logC <- s
 	}
 }
 
@@ -531,7 +1021,9 @@ func (c *Command) ErrLogf(format string, v ...interface{}) {
 // --- Run ---------------------------------------------------------------------
 
 // Run walks the command chain from root down to c calling init functions,
-// invokes c.run, then calls fini and closes log channels on the way back.
+// invokes c.run between the persistent pre- and post-run hooks, passes any
+// error through the OnError interceptors, then calls fini and closes log
+// channels on the way back.
 func (c *Command) Run() error {
 	var cmds []*Command
 	for pc := c; pc != nil; pc = pc.parent {
@@ -561,13 +1053,12 @@ func (c *Command) Run() error {
 			if c.logC == nil && ch != nil {
 				c.logC = ch
 			}
-			if err = c.run(c); err != nil && c.logC != nil {
-				c.ErrLogf("%s", err)
-			}
+			err = c.runHooked(cmds)
 		} else {
 			err = ErrNotRunnable
 		}
 	}
+	err = c.intercept(cmds, err)
 
 	if i < 0 {
 		i = 0
@@ -587,11 +1078,14 @@ func (c *Command) Run() error {
 
 // --- Argument parsing internals ----------------------------------------------
 
+// errf returns a translatable error with the "CommandLine: " prefix.
 func errf(format string, args ...interface{}) error {
-	return fmt.Errorf("CommandLine: "+format, args...)
+	return &msgError{prefix: true, format: format, args: args}
 }
 
-func setNoArgOption(o *Option) {
+// setNoArgOption applies a flag or increment option.  neg selects the
+// "--no-" form of a flag, which stores the opposite value.
+func setNoArgOption(o *Option, neg bool) {
 	if o.incrStep != 0 {
 		if v_, ok := o.v.(*clipInt); ok {
 			*(*int)(v_) += o.incrStep
@@ -600,73 +1094,137 @@ func setNoArgOption(o *Option) {
 		}
 	} else {
 		if v_, ok := o.v.(*clipBool); ok {
-			*(*bool)(v_) = !o.reverseFlag
+			*(*bool)(v_) = o.reverseFlag == neg
 		} else {
 			panic("internal: non-bool Option has zero incrStep")
 		}
 	}
 }
 
-func parseLongOpt(c *Command, name, str string, helpOpt *Option) (consumed int, er error) {
-	kv := strings.Split(name, "=")
-	set := false
-	for _, o := range c.opts {
-		if o == helpOpt {
-			continue
+// longName is one spelling of a long option: its own name, or "no-" plus the
+// name for the automatic negation of a flag.
+type longName struct {
+	name string
+	o    *Option
+	neg  bool
+}
+
+// negatable reports whether o is a flag that accepts "--no-<name>".  Flags
+// whose name already starts with "no-" are left alone.
+func (o *Option) negatable() bool {
+	_, isBool := o.v.(*clipBool)
+	return isBool && !o.hasArg && o.incrStep == 0 && o.longName != "" &&
+		!strings.HasPrefix(o.longName, "no-")
+}
+
+// findLongOpt resolves the long option name key as seen from c.  An exact
+// match always wins; otherwise, if p allows abbreviations, a unique prefix of
+// a long name is accepted.  neg is set when key selects the negated form of a
+// flag.  It returns nil, false, nil if nothing matches.
+func findLongOpt(p *Parser, c *Command, key string) (o *Option, neg bool, er error) {
+	var names []longName
+	builtins := []*Option{&p.helpOption}
+	if slices.Contains(p.Command.opts, &p.versionOption) {
+		builtins = append(builtins, &p.versionOption)
+	}
+	for _, o := range slices.Concat(c.lookupOpts(), builtins) {
+		if o.longName != "" {
+			names = append(names, longName{o.longName, o, false})
 		}
-		if kv[0] != o.longName {
-			continue
+	}
+	for _, o := range c.lookupOpts() {
+		if o.negatable() {
+			names = append(names, longName{"no-" + o.longName, o, true})
 		}
-		if o.status == optStSet && !o.repeatable {
-			er = errf("Option '%s' set more than once", kv[0])
-			return
+	}
+	for _, n := range names {
+		if n.name == key {
+			return n.o, n.neg, nil
 		}
-		if o.hasArg {
-			if len(kv) == 2 {
-				if er = o.v.Parse(kv[1]); er != nil {
-					return
-				}
-				consumed = 1
-			} else if len(str) > 0 {
-				if er = o.v.Parse(str); er != nil {
-					return
-				}
-				consumed = 2
-			} else {
-				er = errf("option '%s' needs an argument", kv[0])
-				return
-			}
-		} else {
-			if len(kv) > 1 {
-				er = errf("option '%s' does not take an argument", kv[0])
-				return
-			}
-			setNoArgOption(o)
-			consumed = 1
+	}
+	if p.strict || key == "" {
+		return nil, false, nil
+	}
+	var matches []longName
+	var cands []string
+	for _, n := range names {
+		// An inherited option shadowed by one of the same name is not a
+		// separate candidate.
+		if strings.HasPrefix(n.name, key) && !slices.Contains(cands, "--"+n.name) {
+			matches = append(matches, n)
+			cands = append(cands, "--"+n.name)
 		}
-		set, o.status = true, optStSet
-		break
 	}
+	if len(matches) > 1 {
+		return nil, false, errf("Option '%s' is ambiguous; possible matches: %s", key, strings.Join(cands, ", "))
+	}
+	if len(matches) == 1 {
+		return matches[0].o, matches[0].neg, nil
+	}
+	return nil, false, nil
+}
 
-	if !set {
-		if kv[0] == helpOpt.longName {
-			return 0, &errHelpRequest{cmd: c, all: false}
-		}
-		if kv[0] == "help-a" {
+func parseLongOpt(r *Result, c *Command, name, str string) (consumed int, er error) {
+	p := r.p
+	key, val, hasVal := strings.Cut(name, "=")
+	o, neg, er := findLongOpt(p, c, key)
+	if er != nil {
+		return 0, er
+	}
+	if o == &p.helpOption {
+		return 0, &errHelpRequest{cmd: c, all: false}
+	}
+	if o == &p.versionOption {
+		return 0, ErrVersion
+	}
+	if o == nil {
+		if key == "help-a" {
 			return 0, &errHelpRequest{cmd: c, all: true}
 		}
-		if er == nil {
-			er = errf("Option '%s' not recognized", kv[0])
+		if key == "print-config" {
+			return 1, r.setPrintConfig(val, hasVal)
 		}
-		consumed = 0
+		return 0, errf("Option '%s' not recognized", key)
+	}
+
+	if r.IsSet(o) && !o.repeatable {
+		return 0, errf("Option '%s' set more than once", o.longName)
+	}
+	if o.hasArg {
+		if hasVal {
+			if er = o.parse(val); er != nil {
+				return 0, er
+			}
+			consumed = 1
+		} else if o.optArg {
+			if er = o.parse(o.implicit); er != nil {
+				return 0, er
+			}
+			consumed = 1
+		} else if len(str) > 0 {
+			if er = o.parse(str); er != nil {
+				return 0, er
+			}
+			consumed = 2
+		} else {
+			return 0, errf("option '%s' needs an argument", o.longName)
+		}
+	} else {
+		if hasVal {
+			return 0, errf("option '%s' does not take an argument", key)
+		}
+		setNoArgOption(o, neg)
+		consumed = 1
 	}
+	r.mark(o, SourceCommandLine)
 	return
 }
 
-func parseShortOpt(c *Command, name, str string, helpOpt *Option) (consumed int, er error) {
+func parseShortOpt(r *Result, c *Command, name, str string) (consumed int, er error) {
+	helpOpt := &r.p.helpOption
 	for len(name) > 0 {
 		var o *Option
-		for _, o_ := range c.opts {
+		for _, o_ := range c.lookupOpts() {
 			if o_ == helpOpt {
 				continue
 			}
@@ -682,34 +1240,41 @@ func parseShortOpt(c *Command, name, str string, helpOpt *Option) (consumed int,
 			er = errf("Option '%s' not recognized", name[:1])
 			break
 		}
-		if o.status == optStSet && !o.repeatable {
+		if r.IsSet(o) && !o.repeatable {
 			er = errf("option '%s' set more than once", name[:1])
 			break
 		}
 		if o.hasArg {
 			if len(name) > 1 {
-				if er = o.v.Parse(name[1:]); er != nil {
+				if er = o.parse(name[1:]); er != nil {
+					return
+				}
+				consumed = 1
+				r.mark(o, SourceCommandLine)
+				break
+			} else if o.optArg {
+				if er = o.parse(o.implicit); er != nil {
 					return
 				}
 				consumed = 1
-				o.status = optStSet
+				r.mark(o, SourceCommandLine)
 				break
 			} else if len(str) > 0 {
-				if er = o.v.Parse(str); er != nil {
+				if er = o.parse(str); er != nil {
 					return
 				}
 				consumed = 2
-				o.status = optStSet
+				r.mark(o, SourceCommandLine)
 				break
 			} else {
 				er = errf("Option '%s' needs an argument", name[:1])
 				break
 			}
 		} else {
-			setNoArgOption(o)
+			setNoArgOption(o, false)
 			name = name[1:]
 			consumed = 1
-			o.status = optStSet
+			r.mark(o, SourceCommandLine)
 		}
 	}
 	if er != nil {
@@ -718,25 +1283,93 @@ func parseShortOpt(c *Command, name, str string, helpOpt *Option) (consumed int,
 	return
 }
 
-func parsePositional(c *Command, str string) (consumed int, er error) {
+// parsePositional queues str in *pending if c still has room for another
+// positional token; assignPositionals parses the queue once all tokens are in.
+func parsePositional(c *Command, pending *[]string, str string) (consumed int, er error) {
+	if positionalCap(c) < 0 || len(*pending) < positionalCap(c) {
+		*pending = append(*pending, str)
+		consumed = 1
+	}
+	return
+}
+
+// positionalCap returns the number of tokens c's positionals can take, or -1
+// if a variadic positional makes it unlimited.
+func positionalCap(c *Command) int {
+	n := len(c.positionals)
 	for _, o := range c.positionals {
-		if o.status == optStSet {
-			continue
+		if o.variadic() {
+			if n < 0 {
+				panic(fmt.Sprintf("internal: command %s has more than one variadic positional", c.Name))
+			}
+			n = -1
 		}
-		if er = o.v.Parse(str); er != nil {
-			return
+	}
+	return n
+}
+
+// assignPositionals distributes toks over c's positionals in declaration
+// order.  Every ArityOne and ArityOneOrMore positional is owed one token; the
+// tokens left over go to ArityOptional positionals (one each, first come
+// first served) and then all to the variadic one.
+//
+// Without a variadic positional, missing ArityOne tokens are tolerated as
+// before (MustSet makes them required).  With one, the owed tokens are
+// required, since otherwise "SRC... DST" could not tell which one is missing.
+func assignPositionals(r *Result, c *Command, toks []string) error {
+	need := 0
+	for _, o := range c.positionals {
+		if o.arity == ArityOne || o.arity == ArityOneOrMore {
+			need++
 		}
-		o.status = optStSet
-		consumed = 1
-		break
 	}
-	return
+	if len(toks) < need && positionalCap(c) < 0 {
+		return errf("expected at least %d positional arguments, got %d", need, len(toks))
+	}
+	// Of the tokens beyond those owed, the optional positionals get theirs
+	// first, wherever they are declared; the variadic one gets the rest.
+	extra, optional := max(len(toks)-need, 0), 0
+	for _, o := range c.positionals {
+		if o.arity == ArityOptional {
+			optional++
+		}
+	}
+	optional = min(optional, extra)
+	extra -= optional
+	for _, o := range c.positionals {
+		var n int
+		switch o.arity {
+		case ArityOne:
+			n = 1
+		case ArityOptional:
+			if optional > 0 {
+				n, optional = 1, optional-1
+			}
+		case ArityOneOrMore:
+			n = 1 + extra
+		case ArityAny:
+			n = extra
+		}
+		n = min(n, len(toks))
+		for _, s := range toks[:n] {
+			if err := o.parse(s); err != nil {
+				return err
+			}
+			r.mark(o, SourceCommandLine)
+		}
+		toks = toks[n:]
+	}
+	return nil
 }
 
 func parseSubCommand(c *Command, str string) (consumed int, sc *Command, er error) {
 	if len(c.subcmds) == 0 {
 		return 0, nil, nil
 	}
+	// An empty token, as quoted in a response file, prefixes every name.
+	if str == "" {
+		return 0, nil, msgf("'%s' not recognized", str)
+	}
 	// Exact name match wins over any longer prefix match (e.g. "gcloud"
 	// selects gcloud, not gcloud-new).
 	for _, s := range c.subcmds {
@@ -747,7 +1380,7 @@ func parseSubCommand(c *Command, str string) (consumed int, sc *Command, er erro
 	for _, s := range c.subcmds {
 		if len(s.Name) > len(str) && strings.HasPrefix(s.Name, str) {
 			if sc != nil {
-				return 0, nil, fmt.Errorf("ambiguous command '%s'", str)
+				return 0, nil, msgf("ambiguous command '%s'", str)
 			}
 			sc = s
 		}
@@ -755,47 +1388,47 @@ func parseSubCommand(c *Command, str string) (consumed int, sc *Command, er erro
 	if sc != nil {
 		consumed = 1
 	} else {
-		er = fmt.Errorf("'%s' not recognized", str)
+		er = msgf("'%s' not recognized", str)
 	}
 	return
 }
 
-func doParse(c *Command, ss []string, helpOpt *Option) (consumed int, sc *Command, er error) {
+func doParse(r *Result, c *Command, ss []string, pending *[]string) (consumed int, sc *Command, er error) {
 	arg0 := ss[0]
 	var arg1 string
 	if len(ss) > 1 {
 		arg1 = ss[1]
 	}
-	if arg0[0] == '-' {
+	if strings.HasPrefix(arg0, "-") {
 		if len(arg0) == 1 {
-			fmt.Println("warning: option '-' ignored")
+			fmt.Fprintln(r.p.output(), r.p.tr("warning: option '-' ignored"))
 			consumed = 1
 		} else if arg0[1] == '-' {
 			if len(arg0) > 2 {
-				consumed, er = parseLongOpt(c, arg0[2:], arg1, helpOpt)
+				consumed, er = parseLongOpt(r, c, arg0[2:], arg1)
 			}
 			// bare "--" is handled in parseCommand before doParse is called
 		} else {
-			consumed, er = parseShortOpt(c, arg0[1:], arg1, helpOpt)
+			consumed, er = parseShortOpt(r, c, arg0[1:], arg1)
 		}
 	} else {
-		if consumed, er = parsePositional(c, arg0); er == nil && consumed == 0 {
+		if consumed, er = parsePositional(c, pending, arg0); er == nil && consumed == 0 {
 			consumed, sc, er = parseSubCommand(c, arg0)
 		}
 	}
 	return
 }
 
-func checkMustSetOptions(c *Command) error {
+func checkMustSetOptions(r *Result, c *Command) error {
 	for c != nil {
 		for _, o := range c.opts {
-			if o.status == optStMustSet {
-				return fmt.Errorf("Option '%s' not given", o.longName)
+			if o.mustSet && !r.IsSet(o) {
+				return msgf("Option '%s' not given", o.longName)
 			}
 		}
 		for _, o := range c.positionals {
-			if o.status == optStMustSet {
-				return fmt.Errorf("positional '%s' not given", o.longName)
+			if o.mustSet && !r.IsSet(o) {
+				return msgf("positional '%s' not given", o.longName)
 			}
 		}
 		c = c.parent
@@ -803,8 +1436,10 @@ func checkMustSetOptions(c *Command) error {
 	return nil
 }
 
-func parseCommand(c *Command, args []string, helpOpt *Option) (*Command, error) {
-	var err error
+// parseCommand parses args for c and its sub-commands, recording the outcome
+// in r.
+func parseCommand(r *Result, c *Command, args []string) error {
+	var pending []string // positional tokens, assigned once all are seen
 	for len(args) > 0 {
 		// "--" ends option processing; remainder goes verbatim into Arguments.
 		if args[0] == "--" {
@@ -816,28 +1451,45 @@ func parseCommand(c *Command, args []string, helpOpt *Option) (*Command, error)
 		if len(args) > 1 {
 			n = 2
 		}
-		consumed, sc, er := doParse(c, args[:n], helpOpt)
+		consumed, sc, er := doParse(r, c, args[:n], &pending)
 		if er != nil {
-			err = er
-			c = nil
-			break
+			return er
 		}
 		if consumed > 0 {
 			args = args[consumed:]
 			if sc != nil {
 				c = sc
 			}
+			// Everything after a plugin's verb belongs to the plugin.
+			if c.plugin != nil {
+				c.Arguments = args
+				break
+			}
 		} else {
 			c.Arguments = args
 			break
 		}
 	}
-	if err == nil {
-		if err = checkMustSetOptions(c); err != nil {
-			c = nil
+	if err := assignPositionals(r, c, pending); err != nil {
+		return err
+	}
+	if err := applySources(r, c); err != nil {
+		return err
+	}
+	// --print-config shows what is missing rather than failing or asking.
+	if r.printConfig == "" {
+		if err := promptMissing(r, c); err != nil {
+			return err
 		}
+		if err := checkMustSetOptions(r, c); err != nil {
+			return err
+		}
+	}
+	for pc := c; pc != nil; pc = pc.parent {
+		r.path = append([]*Command{pc}, r.path...)
 	}
-	return c, err
+	r.args = slices.Clone(c.Arguments)
+	return nil
 }
 
 // --- Help / formatting -------------------------------------------------------
@@ -879,7 +1531,7 @@ func FormatText(text string, width, indent, indentFrom uint) string {
 	return buf.String()
 }
 
-func prtList(lst [][2]string, kind string) (n int) {
+func prtList(wr io.Writer, lst [][2]string, kind string) (n int) {
 	var w int
 	for _, e := range lst {
 		if w < len(e[0]) && len(e[0]) < 32 {
@@ -895,21 +1547,37 @@ func prtList(lst [][2]string, kind string) (n int) {
 	w += 2
 	for i, o := range lst {
 		if i == 0 && kind != "" {
-			fmt.Printf("%s:\n\n", kind)
+			fmt.Fprintf(wr, "%s:\n\n", kind)
 		}
 		if len(o[0]) > w-2 {
-			fmt.Printf("%s\n", o[0])
-			fmt.Printf("%s\n", FormatText(o[1], uint(80-w), uint(w), 0))
+			fmt.Fprintf(wr, "%s\n", o[0])
+			fmt.Fprintf(wr, "%s\n", FormatText(o[1], uint(80-w), uint(w), 0))
 		} else {
-			fmt.Printf("%-[1]*s", w, o[0])
-			fmt.Printf("%s\n", FormatText(o[1], uint(80-w), uint(w), 1))
+			fmt.Fprintf(wr, "%-[1]*s", w, o[0])
+			fmt.Fprintf(wr, "%s\n", FormatText(o[1], uint(80-w), uint(w), 1))
 		}
 		n++
 	}
 	return n
 }
 
-func prtOptions(opts []*Option, kind string, all bool, helpOpt *Option) {
+// positionalName renders a positional's name with its arity as the usage
+// line and the list of positionals in help both show it, e.g. "<SRC>..." for
+// ArityOneOrMore or "[NAME]" for ArityOptional.
+func positionalName(o *Option) string {
+	switch o.arity {
+	case ArityOptional:
+		return "[" + o.longName + "]"
+	case ArityOneOrMore:
+		return "<" + o.longName + ">..."
+	case ArityAny:
+		return "[" + o.longName + "...]"
+	}
+	return "<" + o.longName + ">"
+}
+
+func (p *Parser) prtOptions(w io.Writer, opts []*Option, kind string, all bool) {
+	helpOpt := &p.helpOption
 	var buf bytes.Buffer
 	var lst [][2]string
 	var idx int
@@ -930,65 +1598,65 @@ func prtOptions(opts []*Option, kind string, all bool, helpOpt *Option) {
 			if o.shortName != 0 {
 				buf.WriteByte(',')
 			}
-			if kind == "Options" {
+			if o.negatable() {
+				fmt.Fprintf(&buf, "--[no-]%s", o.longName)
+			} else if !o.pos {
 				fmt.Fprintf(&buf, "--%s", o.longName)
 			} else {
 				idx++
-				fmt.Fprintf(&buf, "%d. %s", idx, o.longName)
+				fmt.Fprintf(&buf, "%d. %s", idx, positionalName(o))
 			}
 		}
 		if o.hasArg {
 			if o.argName == "" {
 				o.argName = "ARG"
 			}
-			fmt.Fprintf(&buf, " <%s>", o.argName)
+			if !o.optArg {
+				fmt.Fprintf(&buf, " <%s>", o.argName)
+			} else if len(o.longName) > 0 {
+				fmt.Fprintf(&buf, "[=%s]", o.argName)
+			} else {
+				fmt.Fprintf(&buf, "[%s]", o.argName)
+			}
 		}
 		ostr := buf.String()
 
 		buf.Reset()
-		buf.WriteString(o.desc)
+		if o == helpOpt || o == &p.versionOption {
+			buf.WriteString(p.tr(o.desc))
+		} else {
+			buf.WriteString(o.desc)
+		}
 		if o.v != nil {
-			if o.status == optStDefault {
-				if dft := o.v.String(); len(dft) > 0 {
-					fmt.Fprintf(&buf, " (default: %s)", dft)
-				}
-			} else if o.status == optStMustSet {
-				buf.WriteString(" (must set)")
+			if len(o.choices) > 0 {
+				fmt.Fprintf(&buf, p.tr(" (one of: %s)"), strings.Join(o.choices, ", "))
+			}
+			if o.env != "" {
+				fmt.Fprintf(&buf, p.tr(" (env: %s)"), o.env)
+			}
+			if o.mustSet {
+				buf.WriteString(p.tr(" (must set)"))
+			} else if dft := o.defaultString(); len(dft) > 0 && !o.secret {
+				fmt.Fprintf(&buf, p.tr(" (default: %s)"), dft)
 			}
 		}
 		lst = append(lst, [2]string{ostr, buf.String()})
 	}
-	if prtList(lst, kind) > 0 {
-		fmt.Println()
+	if prtList(w, lst, kind) > 0 {
+		fmt.Fprintln(w)
 	}
 }
 
-// parseSize converts a human-readable size string to bytes.
-// Recognised suffixes: k/K (×1024), m/M (×1024²), g/G (×1024³).
-// A plain integer with no suffix is returned as-is.
+// parseSize converts a log rotation size such as "10M" or "512MiB" to bytes
+// using [ParseByteSize].  A plain integer with no suffix is returned as-is.
 // An empty string returns 0 (callers interpret 0 as "no limit").
 func parseSize(sz string) (n int64, err error) {
-	var factor int64
 	if len(sz) > 0 {
-		switch sz[len(sz)-1] {
-		case 'k', 'K':
-			factor = 1024
-		case 'm', 'M':
-			factor = 1024 * 1024
-		case 'g', 'G':
-			factor = 1024 * 1024 * 1024
-		}
-		if factor > 0 {
-			sz = sz[:len(sz)-1]
-		}
-		n, err = strconv.ParseInt(sz, 0, 64)
-		if err == nil {
-			if factor > 0 {
-				n *= factor
-			}
-		} else {
-			err = fmt.Errorf("invalid log size %s", sz)
+		var b ByteSize
+		if b, err = ParseByteSize(sz); err != nil {
+			err = msgf("invalid log size %s", sz)
 		}
+		n = int64(b)
 	}
 	return
 }
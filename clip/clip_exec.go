@@ -0,0 +1,78 @@
+package clip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// ExitCoder is implemented by errors that carry the exit status the program
+// should end with.  A run function returns one to make [Parser.Execute] exit
+// with a status other than 1; *exec.ExitError, returned for a failed plugin,
+// is one.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// SetParseErrorCode sets the exit status [Parser.Execute] returns when the
+// command line cannot be parsed.  The default is 2, as with most Unix tools.
+// Returns p so calls can be chained.
+func (p *Parser) SetParseErrorCode(code int) *Parser {
+	p.parseErrCode = code
+	return p
+}
+
+// SetErrOutput sets the writer [Parser.Execute] reports errors to; nil, the
+// default, means os.Stderr.  Returns p so calls can be chained.
+func (p *Parser) SetErrOutput(w io.Writer) *Parser {
+	p.errOut = w
+	return p
+}
+
+func (p *Parser) errOutput() io.Writer {
+	if p.errOut == nil {
+		return os.Stderr
+	}
+	return p.errOut
+}
+
+// Execute parses args as [Parser.Parse] does, runs the matched command and
+// closes p, and returns the status the program should exit with:
+//
+//	0  help, --version or --print-config was requested, or the command
+//	   succeeded
+//	2  the command line could not be parsed (see SetParseErrorCode)
+//	n  the command failed with an error implementing ExitCoder
+//	1  the command failed with any other error
+//
+// Errors are written to stderr (see SetErrOutput), except the exit status of
+// a plugin, which reports its own errors.  A typical main is
+//
+//	os.Exit(p.Execute(nil))
+func (p *Parser) Execute(args []string) int {
+	defer p.Close()
+	cmd, err := p.Parse(args)
+	if errors.Is(err, ErrHelp) || errors.Is(err, ErrVersion) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(p.errOutput(), err)
+		return p.parseErrCode
+	}
+	if err = cmd.Run(); err == nil {
+		return 0
+	}
+	var ee *exec.ExitError
+	if err == ErrNotRunnable {
+		fmt.Fprintln(p.errOutput(), p.tr(err.Error()))
+	} else if cmd.plugin == nil || !errors.As(err, &ee) {
+		fmt.Fprintln(p.errOutput(), err)
+	}
+	var ec ExitCoder
+	if errors.As(err, &ec) && ec.ExitCode() > 0 {
+		return ec.ExitCode()
+	}
+	return 1
+}
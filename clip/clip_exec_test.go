@@ -0,0 +1,78 @@
+package clip
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type exitErr int
+
+func (e exitErr) Error() string { return fmt.Sprintf("failed with %d", int(e)) }
+func (e exitErr) ExitCode() int { return int(e) }
+
+func TestExecute(t *testing.T) {
+	newParser := func(stderr io.Writer) *Parser {
+		p := New().SetOutput(io.Discard).SetLogOutput(io.Discard).SetErrOutput(stderr)
+		p.SubCommand("ok", "", "").SetRuns(func(*Command) error { return nil }, nil, nil)
+		p.SubCommand("fail", "", "").SetRuns(func(*Command) error { return errors.New("boom") }, nil, nil)
+		p.SubCommand("exit", "", "").SetRuns(func(*Command) error {
+			return fmt.Errorf("wrapped: %w", exitErr(75))
+		}, nil, nil)
+		p.SubCommand("none", "", "")
+		return p
+	}
+	tests := []struct {
+		args   string
+		code   int
+		stderr string
+	}{
+		{"ok", 0, ""},
+		{"--help", 0, ""},
+		{"--print-config", 0, ""},
+		{"--bogus", 2, "CommandLine: Option 'bogus' not recognized\n"},
+		{"fail", 1, "boom\n"},
+		{"exit", 75, "wrapped: failed with 75\n"},
+		{"none", 1, "command not runnable\n"},
+	}
+	for _, tt := range tests {
+		var stderr bytes.Buffer
+		code := newParser(&stderr).Execute(append([]string{"prog"}, strings.Fields(tt.args)...))
+		if code != tt.code || stderr.String() != tt.stderr {
+			t.Errorf("%q: code %d, stderr %q; want %d, %q", tt.args, code, stderr.String(), tt.code, tt.stderr)
+		}
+	}
+
+	p := newParser(io.Discard).SetParseErrorCode(64)
+	if code := p.Execute([]string{"prog", "--bogus"}); code != 64 {
+		t.Errorf("code = %d with SetParseErrorCode(64)", code)
+	}
+}
+
+func TestExecutePlugin(t *testing.T) {
+	p, _ := newPluginParser(t)
+	var stderr bytes.Buffer
+	if code := p.SetErrOutput(&stderr).Execute([]string{"tool", "greet"}); code != 3 || stderr.Len() != 0 {
+		t.Errorf("code %d, stderr %q; want the plugin's status and no message", code, stderr.String())
+	}
+}
+
+func TestExecuteChildExitError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	// A built-in command that runs a child process reports its failure.
+	p := New().SetLogOutput(io.Discard)
+	p.SubCommand("build", "", "").SetRuns(func(*Command) error {
+		return exec.Command("sh", "-c", "exit 4").Run()
+	}, nil, nil)
+	var stderr bytes.Buffer
+	if code := p.SetErrOutput(&stderr).Execute([]string{"prog", "build"}); code != 4 || stderr.String() != "exit status 4\n" {
+		t.Errorf("code %d, stderr %q; want 4, %q", code, stderr.String(), "exit status 4\n")
+	}
+}
@@ -0,0 +1,79 @@
+package clip
+
+// PersistentPreRun registers f to run before the run function of c and of
+// every command below it.  f receives the command being run, not c.  When
+// several commands on the path from the root have one, they run outermost
+// first, after all init functions; an error skips the remaining hooks and the
+// run function, and is returned by [Command.Run].  Returns c so calls can be
+// chained.
+func (c *Command) PersistentPreRun(f func(c *Command) error) *Command {
+	c.preRun = f
+	return c
+}
+
+// PersistentPostRun registers f to run after the run function of c and of
+// every command below it, whether or not that succeeded, as long as c's
+// PersistentPreRun was reached.  f receives the command being run.  Post-run
+// hooks run innermost first, before any fini function; the first error is
+// returned by [Command.Run].  Returns c so calls can be chained.
+func (c *Command) PersistentPostRun(f func(c *Command) error) *Command {
+	c.postRun = f
+	return c
+}
+
+// OnError registers f to see any error [Command.Run] is about to return for
+// c or a command below it, including those of init functions and hooks.  f
+// receives the command being run and the error, and returns the error to
+// pass on: err itself, a wrapped error, or nil to suppress it.  Interceptors
+// run innermost first, before any fini function, so they can still log; the
+// chain stops once one returns nil.  Returns c so calls can be chained.
+func (c *Command) OnError(f func(c *Command, err error) error) *Command {
+	c.onError = f
+	return c
+}
+
+// runHooked calls the persistent hooks of cmds, which holds c and its
+// parents with the root last, around c's run function.
+func (c *Command) runHooked(cmds []*Command) error {
+	var err error
+	i := len(cmds) - 1
+	for ; i >= 0; i-- {
+		if f := cmds[i].preRun; f != nil {
+			if err = f(c); err != nil {
+				c.ErrLogf("%s", err)
+				break
+			}
+		}
+	}
+	if err == nil {
+		if err = c.run(c); err != nil {
+			c.ErrLogf("%s", err)
+		}
+	}
+	// As with fini, the command whose hook failed is unwound too.
+	for i = max(i, 0); i < len(cmds); i++ {
+		if f := cmds[i].postRun; f != nil {
+			if perr := f(c); perr != nil {
+				c.ErrLogf("%s", perr)
+				if err == nil {
+					err = perr
+				}
+			}
+		}
+	}
+	return err
+}
+
+// intercept passes err through the OnError interceptors of cmds, innermost
+// first.
+func (c *Command) intercept(cmds []*Command, err error) error {
+	for _, pc := range cmds {
+		if err == nil {
+			break
+		}
+		if pc.onError != nil {
+			err = pc.onError(c, err)
+		}
+	}
+	return err
+}
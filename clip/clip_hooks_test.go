@@ -0,0 +1,92 @@
+package clip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+)
+
+// newHookParser returns a root > db > migrate tree whose functions and hooks
+// append to trace; fail names the one that returns an error.
+func newHookParser(trace *[]string, fail string) (*Parser, *Command) {
+	p := New().SetLogOutput(io.Discard)
+	p.Name = "root"
+	fn := func(name string) func(*Command) error {
+		return func(c *Command) error {
+			*trace = append(*trace, name+"("+c.Name+")")
+			if name == fail {
+				return errors.New(name + " failed")
+			}
+			return nil
+		}
+	}
+	db := p.SubCommand("db", "", "")
+	migrate := db.SubCommand("migrate", "", "")
+	for _, c := range []*Command{&p.Command, db} {
+		c.SetRuns(nil, fn(c.Name+".init"), fn(c.Name+".fini"))
+		c.PersistentPreRun(fn(c.Name + ".pre")).PersistentPostRun(fn(c.Name + ".post"))
+	}
+	migrate.SetRuns(fn("run"), nil, nil)
+	return p, migrate
+}
+
+func TestPersistentHooks(t *testing.T) {
+	tests := []struct {
+		fail string
+		want []string
+	}{
+		{"", []string{"root.init(root)", "db.init(db)", "root.pre(migrate)", "db.pre(migrate)", "run(migrate)",
+			"db.post(migrate)", "root.post(migrate)", "db.fini(db)", "root.fini(root)"}},
+		{"run", []string{"root.init(root)", "db.init(db)", "root.pre(migrate)", "db.pre(migrate)", "run(migrate)",
+			"db.post(migrate)", "root.post(migrate)", "db.fini(db)", "root.fini(root)"}},
+		{"root.pre", []string{"root.init(root)", "db.init(db)", "root.pre(migrate)",
+			"root.post(migrate)", "db.fini(db)", "root.fini(root)"}},
+		{"db.init", []string{"root.init(root)", "db.init(db)", "db.fini(db)", "root.fini(root)"}},
+	}
+	for _, tt := range tests {
+		var trace []string
+		p, leaf := newHookParser(&trace, tt.fail)
+		if _, err := p.Parse([]string{"root", "db", "migrate"}); err != nil {
+			t.Fatal(err)
+		}
+		err := leaf.Run()
+		if tt.fail == "" && err != nil || tt.fail != "" && (err == nil || err.Error() != tt.fail+" failed") {
+			t.Errorf("fail %q: err = %v", tt.fail, err)
+		}
+		if !slices.Equal(trace, tt.want) {
+			t.Errorf("fail %q:\n got %q\nwant %q", tt.fail, trace, tt.want)
+		}
+	}
+}
+
+func TestOnError(t *testing.T) {
+	var trace []string
+	p, leaf := newHookParser(&trace, "run")
+	p.OnError(func(c *Command, err error) error {
+		return fmt.Errorf("%s: %w", c.Name, err)
+	})
+	sentinel := errors.New("ignored")
+	leaf.OnError(func(c *Command, err error) error {
+		if errors.Is(err, sentinel) {
+			return nil
+		}
+		return err
+	})
+
+	if _, err := p.Parse([]string{"root", "db", "migrate"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := leaf.Run(); err == nil || err.Error() != "migrate: run failed" {
+		t.Errorf("err = %v; want the root interceptor's wrapping", err)
+	}
+
+	leaf.SetRuns(func(*Command) error { return sentinel }, nil, nil)
+	if _, err := p.Parse([]string{"root", "db", "migrate"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := leaf.Run(); err != nil {
+		t.Errorf("err = %v; want it suppressed", err)
+	}
+}
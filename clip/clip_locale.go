@@ -0,0 +1,155 @@
+package clip
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Catalog translates clip's user-facing messages for one locale.  Keys are
+// the English texts as they appear in clip's source: help headings such as
+// "Options" and "Sub-Commands", annotations such as " (default: %s)", the
+// descriptions of the help and version options, [Source] names as printed
+// by --print-config, and warning and error formats such as
+// "Option '%s' not recognized".  Values are the
+// translations, with the same verbs in the same order.  A message missing
+// from a territory's catalog is looked up in the language's, then shown in
+// English.
+type Catalog map[string]string
+
+var (
+	catalogsMu sync.RWMutex
+	catalogs   = map[string]Catalog{}
+)
+
+// RegisterCatalog makes c available for locale, which is a language ("de")
+// or a language and territory ("pt_BR").  A later call for the same locale
+// replaces the earlier catalog.
+func RegisterCatalog(locale string, c Catalog) {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+	catalogs[normLocale(locale)] = c
+}
+
+// SetLocale selects the catalog p uses for help and error messages.  With
+// an empty locale (the default), it is taken from the first of LC_ALL,
+// LC_MESSAGES and LANG that is set.  "de_DE.UTF-8" uses the catalogs
+// registered for "de_DE" and "de"; "C", "POSIX" and locales without a
+// catalog give English.  Returns p so calls can be chained.
+func (p *Parser) SetLocale(locale string) *Parser {
+	p.locale = locale
+	return p
+}
+
+// normLocale strips the encoding and modifier from a POSIX locale name, as
+// in "de_DE.UTF-8@euro", and accepts "-" as well as "_".
+func normLocale(s string) string {
+	s, _, _ = strings.Cut(s, ".")
+	s, _, _ = strings.Cut(s, "@")
+	return strings.ReplaceAll(s, "-", "_")
+}
+
+// catalogs returns the catalogs for p's locale, most specific first, or nil
+// for English.
+func (p *Parser) catalogs() []Catalog {
+	loc := p.locale
+	if loc == "" {
+		for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+			if loc = os.Getenv(env); loc != "" {
+				break
+			}
+		}
+	}
+	loc = normLocale(loc)
+	lang, _, _ := strings.Cut(loc, "_")
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+	var cs []Catalog
+	if c, ok := catalogs[loc]; ok {
+		cs = append(cs, c)
+	}
+	if c, ok := catalogs[lang]; ok && lang != loc {
+		cs = append(cs, c)
+	}
+	return cs
+}
+
+// tr translates msg into p's locale.
+func (p *Parser) tr(msg string) string {
+	for _, c := range p.catalogs() {
+		if s := c[msg]; s != "" {
+			return s
+		}
+	}
+	return msg
+}
+
+// msgError is a user-facing error whose text can be translated after the
+// fact: it keeps the English format and arguments, and Parse renders it in
+// the parser's locale.
+type msgError struct {
+	prefix bool // prepend "CommandLine: "
+	format string
+	args   []interface{}
+}
+
+func (e *msgError) Error() string { return e.render(nil) }
+
+// Unwrap returns the errors among e's arguments, so errors.Is and errors.As
+// see the causes, e.g. a file's os.ErrNotExist.
+func (e *msgError) Unwrap() []error {
+	var errs []error
+	for _, a := range e.args {
+		if err, ok := a.(error); ok {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// render formats e, translating the format, the prefix, and any msgError
+// arguments with p, or leaving them in English if p is nil.
+func (e *msgError) render(p *Parser) string {
+	tr := func(s string) string { return s }
+	if p != nil {
+		tr = p.tr
+	}
+	args := make([]interface{}, len(e.args))
+	for i, a := range e.args {
+		if me, ok := a.(*msgError); ok {
+			a = me.render(p)
+		}
+		args[i] = a
+	}
+	s := fmt.Sprintf(tr(e.format), args...)
+	if e.prefix {
+		s = tr("CommandLine: ") + s
+	}
+	return s
+}
+
+// msgf returns a translatable error.
+func msgf(format string, args ...interface{}) error {
+	return &msgError{format: format, args: args}
+}
+
+// localize returns err in p's locale.  Errors that clip did not create, such
+// as those from custom option types, are returned as they are.
+func (p *Parser) localize(err error) error {
+	me, ok := err.(*msgError)
+	if !ok || p.catalogs() == nil {
+		return err
+	}
+	return &localizedError{msg: me.render(p), err: me}
+}
+
+// localizedError is a msgError rendered in a parser's locale.  It unwraps to
+// the original, so callers can still test for the errors it wraps.
+type localizedError struct {
+	msg string
+	err error
+}
+
+func (e *localizedError) Error() string { return e.msg }
+func (e *localizedError) Unwrap() error { return e.err }
@@ -0,0 +1,154 @@
+package clip
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The tests use Esperanto, which is unlikely to be the locale of the machine
+// running them.
+var esperanto = Catalog{
+	"Usage":                                  "Uzado",
+	"Options":                                "Opcioj",
+	"Sub-Commands":                           "Subkomandoj",
+	" (default: %s)":                         " (defaŭlte: %s)",
+	" (must set)":                            " (deviga)",
+	"CommandLine: ":                          "Komandlinio: ",
+	"Option '%s' not recognized":             "Opcio '%s' ne rekonita",
+	"'%s' not recognized":                    "'%s' ne rekonita",
+	"Option '%s' not given":                  "Opcio '%s' ne donita",
+	"unterminated single quote at offset %d": "nefermita citilo ĉe %d",
+	"Version information":                    "Versia informo",
+	"command not runnable":                   "komando ne rulebla",
+	"command line":                           "komandlinio",
+	"warn: failed to open log file '%s'":     "averto: ne povis malfermi protokolon '%s'",
+}
+
+// useCatalog registers c for locale until the test ends.
+func useCatalog(t *testing.T, locale string, c Catalog) {
+	t.Helper()
+	locale = normLocale(locale)
+	catalogsMu.RLock()
+	prev, had := catalogs[locale]
+	catalogsMu.RUnlock()
+	RegisterCatalog(locale, c)
+	t.Cleanup(func() {
+		catalogsMu.Lock()
+		defer catalogsMu.Unlock()
+		if had {
+			catalogs[locale] = prev
+		} else {
+			delete(catalogs, locale)
+		}
+	})
+}
+
+func newLocaleParser(t *testing.T, locale string) (*Parser, *bytes.Buffer) {
+	t.Helper()
+	useCatalog(t, "eo", esperanto)
+	useCatalog(t, "eo_XX", Catalog{"Options": "Elektoj"})
+	var out bytes.Buffer
+	p := New().SetLocale(locale).SetOutput(&out)
+	port, name := 80, ""
+	p.ArgOption(&port, 'p', "port", "PORT", "")
+	p.ArgOption(&name, 'n', "name", "NAME", "").MustSet()
+	p.SubCommand("serve", "", "")
+	return p, &out
+}
+
+func TestLocaleHelp(t *testing.T) {
+	p, out := newLocaleParser(t, "eo.UTF-8")
+	defer p.Close()
+
+	p.HelpCommand(nil, false)
+	for _, want := range []string{"Uzado: ", "Opcioj:", "Subkomandoj:", "(defaŭlte: 80)", "(deviga)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("help lacks %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestLocaleErrors(t *testing.T) {
+	p, _ := newLocaleParser(t, "eo")
+	defer p.Close()
+
+	tests := []struct{ args, want string }{
+		{"--bogus", "Komandlinio: Opcio 'bogus' ne rekonita"},
+		{"-n x bogus", "'bogus' ne rekonita"},
+		{"", "Opcio 'name' ne donita"},
+	}
+	for _, tt := range tests {
+		_, err := p.Parse(append([]string{"prog"}, strings.Fields(tt.args)...))
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%q: err = %v; want %q", tt.args, err, tt.want)
+		}
+	}
+	// Translation keeps the wrapped errors.
+	p.ResponseFiles(true)
+	_, err := p.Parse([]string{"prog", "@" + filepath.Join(t.TempDir(), "missing")})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("err = %v; want it to wrap os.ErrNotExist", err)
+	}
+	_, err = p.ParseString("-n 'x")
+	if want := "nefermita citilo ĉe 3"; err == nil || err.Error() != want {
+		t.Errorf("ParseString: err = %v; want %q", err, want)
+	}
+}
+
+func TestLocaleBuiltins(t *testing.T) {
+	p, out := newLocaleParser(t, "eo")
+	p.Version("1.0")
+	p.HelpCommand(nil, false)
+	if !strings.Contains(out.String(), "Versia informo") {
+		t.Errorf("help lacks the translated --version description:\n%s", out.String())
+	}
+
+	out.Reset()
+	p.Parse([]string{"prog", "-n", "x", "--print-config"})
+	if !strings.Contains(out.String(), "(komandlinio)") {
+		t.Errorf("--print-config lacks the translated source:\n%s", out.String())
+	}
+
+	var stderr bytes.Buffer
+	if p.SetErrOutput(&stderr).Execute([]string{"prog", "-n", "x", "serve"}); stderr.String() != "komando ne rulebla\n" {
+		t.Errorf("Execute wrote %q; want the translated ErrNotRunnable", stderr.String())
+	}
+
+	q, out := newLocaleParser(t, "eo")
+	q.OpenLogfile(filepath.Join(t.TempDir(), "missing", "log"), "")
+	cmd, err := q.Parse([]string{"prog", "-n", "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd.Logf("hello")
+	q.Close()
+	if !strings.HasPrefix(out.String(), "averto: ne povis malfermi protokolon '") {
+		t.Errorf("log warning not translated or not on the parser's output: %q", out.String())
+	}
+}
+
+func TestLocaleSelection(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "eo_XX.UTF-8")
+	t.Setenv("LANG", "C")
+
+	p, _ := newLocaleParser(t, "")
+	defer p.Close()
+	if got := p.tr("Options"); got != "Elektoj" {
+		t.Errorf("tr(Options) with LC_MESSAGES=eo_XX = %q", got)
+	}
+	// Falls back from territory to language, then to English.
+	if got := p.tr("Usage"); got != "Uzado" {
+		t.Errorf("tr(Usage) = %q; want the eo translation", got)
+	}
+	if got := p.tr("Positionals"); got != "Positionals" {
+		t.Errorf("tr(Positionals) = %q; want English", got)
+	}
+	if got := p.SetLocale("C").tr("Options"); got != "Options" {
+		t.Errorf("tr(Options) with locale C = %q", got)
+	}
+}
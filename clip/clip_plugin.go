@@ -0,0 +1,97 @@
+package clip
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// describeTimeout bounds how long a plugin may take to answer --clip-describe.
+const describeTimeout = 2 * time.Second
+
+// plugin is the state of a sub-command created by DiscoverPlugins.
+type plugin struct {
+	path      string
+	described bool // desc has been asked for, successfully or not
+}
+
+// DiscoverPlugins adds a sub-command for every executable named
+// "<prog>-<verb>" in dirs, or in the directories of $PATH if dirs is empty,
+// like git does for "git-<verb>".  <prog> is p.Name, or the base name of
+// os.Args[0] if that is empty.  A verb already registered, or found in an
+// earlier directory, is skipped.  Call it after registering the built-in
+// sub-commands.
+//
+// A plugin sub-command takes every token after its verb, options included,
+// as Command.Arguments.  Its Run executes the plugin with those arguments,
+// the program's environment, stdin, stdout and stderr; a non-zero exit
+// status is returned as an *exec.ExitError.
+//
+// Help shows the first line a plugin prints when run with the single
+// argument --clip-describe.  Plugins are only asked when help is printed.
+// Returns p so calls can be chained.
+func (p *Parser) DiscoverPlugins(dirs ...string) *Parser {
+	if len(dirs) == 0 {
+		dirs = filepath.SplitList(os.Getenv("PATH"))
+	}
+	prefix := p.progName() + "-"
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			verb, ok := strings.CutPrefix(e.Name(), prefix)
+			if runtime.GOOS == "windows" {
+				var exe bool
+				verb, exe = strings.CutSuffix(verb, ".exe")
+				ok = ok && exe
+			}
+			if !ok || verb == "" || p.hasSubCommand(verb) {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			sc := p.SubCommand(verb, "", "").AcceptArguments("ARGS")
+			sc.plugin = &plugin{path: path}
+			sc.run = runPlugin
+		}
+	}
+	return p
+}
+
+func isExecutable(path string) bool {
+	fi, err := os.Stat(path)
+	if err != nil || !fi.Mode().IsRegular() {
+		return false
+	}
+	return runtime.GOOS == "windows" || fi.Mode()&0111 != 0
+}
+
+func runPlugin(c *Command) error {
+	cmd := exec.Command(c.plugin.path, c.Arguments...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// description returns c's short description, asking a plugin for it the
+// first time.
+func (c *Command) description() string {
+	if c.plugin != nil && !c.plugin.described {
+		c.plugin.described = true
+		ctx, cancel := context.WithTimeout(context.Background(), describeTimeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, c.plugin.path, "--clip-describe").Output()
+		if err == nil {
+			line, _, _ := strings.Cut(string(out), "\n")
+			c.desc = strings.TrimSpace(line)
+		}
+	}
+	return c.desc
+}
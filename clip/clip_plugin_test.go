@@ -0,0 +1,116 @@
+package clip
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writePlugin creates an executable shell script dir/name with body.
+func writePlugin(t *testing.T, dir, name, body string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newPluginParser(t *testing.T) (*Parser, string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	writePlugin(t, dir, "tool-greet", `
+if [ "$1" = --clip-describe ]; then echo "Greet someone"; echo ignored; exit 0; fi
+echo "$GREETING $*" > `+out+`
+exit 3
+`)
+	writePlugin(t, dir, "tool-serve", "echo plugin\n")
+	writePlugin(t, dir, "other-x", "exit 0\n")
+	os.WriteFile(filepath.Join(dir, "tool-notexec"), nil, 0644)
+
+	p := New()
+	p.Name = "tool"
+	p.SubCommand("serve", "Built-in serve", "")
+	return p.DiscoverPlugins(dir), out
+}
+
+func TestDiscoverPluginsArgv0(t *testing.T) {
+	_, _ = newPluginParser(t) // skips on Windows
+	dir := t.TempDir()
+	writePlugin(t, dir, "tool-greet", "exit 0\n")
+
+	args0 := os.Args[0]
+	os.Args[0] = filepath.Join("/usr/local/bin", "tool")
+	defer func() { os.Args[0] = args0 }()
+	p := New().DiscoverPlugins(dir)
+	defer p.Close()
+	if len(p.subcmds) != 1 || p.subcmds[0].Name != "greet" {
+		t.Errorf("plugins found with argv[0] %q and no Name: %v", os.Args[0], p.subcmds)
+	}
+}
+
+func TestBaseName(t *testing.T) {
+	tests := []struct{ path, goos, want string }{
+		{"/usr/bin/tool", "linux", "tool"},
+		{"tool.exe", "linux", "tool.exe"},
+		{"C:/bin/tool.exe", "windows", "tool"},
+		{"tool", "windows", "tool"},
+	}
+	for _, tt := range tests {
+		if got := baseName(tt.path, tt.goos); got != tt.want {
+			t.Errorf("baseName(%q, %s) = %q; want %q", tt.path, tt.goos, got, tt.want)
+		}
+	}
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	p, _ := newPluginParser(t)
+	defer p.Close()
+
+	var names []string
+	for _, sc := range p.subcmds {
+		names = append(names, sc.Name)
+	}
+	if want := []string{"serve", "greet"}; !slices.Equal(names, want) {
+		t.Errorf("sub-commands = %q; want %q", names, want)
+	}
+
+	var out bytes.Buffer
+	p.SetOutput(&out)
+	p.HelpCommand(nil, false)
+	if !strings.Contains(out.String(), "greet") || !strings.Contains(out.String(), "Greet someone") ||
+		strings.Contains(out.String(), "ignored") {
+		t.Errorf("help:\n%s", out.String())
+	}
+}
+
+func TestRunPlugin(t *testing.T) {
+	p, out := newPluginParser(t)
+	var verbose bool
+	p.FlagOption(&verbose, 'v', "verbose", "")
+	t.Setenv("GREETING", "hello")
+
+	c, err := p.Parse([]string{"tool", "-v", "greet", "--name", "bob", "-v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verbose || !slices.Equal(c.Arguments, []string{"--name", "bob", "-v"}) {
+		t.Errorf("verbose = %v, Arguments = %q", verbose, c.Arguments)
+	}
+	err = c.Run()
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) || ee.ExitCode() != 3 {
+		t.Errorf("Run() = %v; want exit status 3", err)
+	}
+	data, _ := os.ReadFile(out)
+	if got := string(data); got != "hello --name bob -v\n" {
+		t.Errorf("plugin saw %q", got)
+	}
+}
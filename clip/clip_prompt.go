@@ -0,0 +1,184 @@
+package clip
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// EnablePrompt makes Parse ask for the value of each [Option.MustSet] option
+// or positional that no source supplied, instead of failing.  Prompts are
+// written to out and answers read from in, one line each; nil means
+// os.Stderr and os.Stdin.  If in is an *os.File that is not a terminal, as
+// when a script pipes into the program, nothing is asked and Parse fails as
+// before.  Other readers are always used, which lets tests script the
+// answers.  Answers to [Option.Secret] options are not echoed when in is a
+// terminal.  An answer is the value itself, even for a [Secret] or
+// [Option.FromFile] option, so "-" is not read from stdin.  Returns p so
+// calls can be chained.
+func (p *Parser) EnablePrompt(in io.Reader, out io.Writer) *Parser {
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stderr
+	}
+	p.prompt = &prompter{in: bufio.NewReader(in), out: out}
+	if f, ok := in.(*os.File); ok {
+		p.prompt.tty = f
+	}
+	return p
+}
+
+// prompter holds the state of EnablePrompt.  tty is the terminal answers
+// are read from, if in is an *os.File.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	tty *os.File
+}
+
+// Choices restricts o to the given values, wherever they come from.  Help
+// lists them, and a prompt for o shows them as a numbered menu.
+func (o *Option) Choices(values ...string) *Option {
+	if !o.hasArg && !o.pos {
+		panic("Choices on Option without argument")
+	}
+	o.choices = values
+	o.v = &choiceValue{IOption: o.v, choices: values}
+	return o
+}
+
+// Suggest sets the answer used when the user just presses Enter at a prompt
+// for o (see [Parser.EnablePrompt]).  Unlike a default, it is never used
+// without asking.
+func (o *Option) Suggest(v string) *Option {
+	o.suggest = v
+	return o
+}
+
+// choiceValue rejects values outside choices before passing them on.
+type choiceValue struct {
+	IOption
+	choices []string
+}
+
+func (c *choiceValue) Parse(s string) error {
+	if !slices.Contains(c.choices, s) {
+		return msgf("'%s' is not one of %s", s, strings.Join(c.choices, ", "))
+	}
+	return c.IOption.Parse(s)
+}
+
+func (c *choiceValue) snapshot() func() { return snapshot(c.IOption) }
+
+// promptMissing asks for every MustSet option and positional on the path to
+// c that is still unset, root first, if prompting is enabled and possible.
+// Running out of input leaves the rest unset for checkMustSetOptions.
+func promptMissing(r *Result, c *Command) error {
+	pr := r.p.prompt
+	if pr == nil || (pr.tty != nil && !isTerminal(pr.tty)) {
+		return nil
+	}
+	var path []*Command
+	for pc := c; pc != nil; pc = pc.parent {
+		path = append([]*Command{pc}, path...)
+	}
+	for _, pc := range path {
+		opts := pc.opts
+		if pc == c {
+			opts = slices.Concat(opts, c.positionals)
+		}
+		for _, o := range opts {
+			if !o.mustSet || r.IsSet(o) {
+				continue
+			}
+			ok, err := pr.ask(r.p, o)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+			r.mark(o, SourcePrompt)
+		}
+	}
+	return nil
+}
+
+// ask prompts for o until it gets a valid answer, which it stores in o.  It
+// returns false if the input ends first.  p supplies the translations.
+func (pr *prompter) ask(p *Parser, o *Option) (bool, error) {
+	label := "--" + o.longName
+	if o.pos {
+		label = o.longName
+	} else if o.longName == "" {
+		label = "-" + string(o.shortName)
+	}
+	if o.desc != "" {
+		label = o.desc + " (" + label + ")"
+	}
+	for {
+		if len(o.choices) > 0 {
+			fmt.Fprintf(pr.out, "%s:\n", label)
+			for i, ch := range o.choices {
+				fmt.Fprintf(pr.out, "  %d) %s\n", i+1, ch)
+			}
+			fmt.Fprintf(pr.out, p.tr("Choose 1-%d"), len(o.choices))
+		} else {
+			fmt.Fprint(pr.out, label)
+		}
+		if o.suggest != "" && !o.secret {
+			fmt.Fprintf(pr.out, " [%s]", o.suggest)
+		}
+		fmt.Fprint(pr.out, ": ")
+
+		line, err := pr.readLine(o.secret)
+		if err != nil {
+			fmt.Fprintln(pr.out)
+			if err == io.EOF {
+				return false, nil
+			}
+			return false, errf("prompt: %v", err)
+		}
+		if line == "" {
+			line = o.suggest
+		}
+		if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(o.choices) {
+			line = o.choices[n-1]
+		}
+		if line == "" {
+			fmt.Fprintln(pr.out, p.tr("A value is required."))
+			continue
+		}
+		if err = o.parseLiteral(line); err != nil {
+			fmt.Fprintln(pr.out, p.localize(err))
+			continue
+		}
+		return true, nil
+	}
+}
+
+// readLine reads one answer without its line ending, with echo turned off
+// if hidden is set and answers come from a terminal.  A final line without
+// a newline counts as an answer.
+func (pr *prompter) readLine(hidden bool) (string, error) {
+	if hidden && pr.tty != nil {
+		restore, err := disableEcho(pr.tty)
+		if err == nil {
+			defer func() {
+				restore()
+				fmt.Fprintln(pr.out) // the user's Enter was not echoed
+			}()
+		}
+	}
+	line, err := pr.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
@@ -0,0 +1,127 @@
+package clip
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPromptMissing(t *testing.T) {
+	p := New()
+	defer p.Close()
+	var out strings.Builder
+	p.EnablePrompt(strings.NewReader("\nx\n2\nhunter2\nhttps://x\n"), &out)
+
+	var env, level, password, url string
+	p.ArgOption(&env, 'e', "env", "ENV", "Target environment").MustSet().Suggest("staging")
+	p.ArgOption(&level, 'l', "level", "LEVEL", "").MustSet().Choices("debug", "info", "warn")
+	p.ArgOption(&password, 0, "password", "PW", "").MustSet().Secret().Suggest("nope")
+	p.Positional(&url, "URL", "").MustSet()
+
+	r, err := p.ParseResult([]string{"prog"})
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out.String())
+	}
+	if env != "staging" || level != "info" || password != "hunter2" || url != "https://x" {
+		t.Errorf("env=%q level=%q password=%q url=%q", env, level, password, url)
+	}
+	if s := r.Source(p.opts[1]); s != SourcePrompt {
+		t.Errorf("Source(level) = %v; want prompt", s)
+	}
+	for _, want := range []string{
+		"Target environment (--env) [staging]: ",
+		"--level:\n  1) debug\n  2) info\n  3) warn\nChoose 1-3: ",
+		"'x' is not one of debug, info, warn\n",
+		"--password: ",
+		"URL: ",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("prompt output lacks %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "nope") {
+		t.Errorf("suggestion for secret shown:\n%s", out.String())
+	}
+}
+
+func TestPromptSecretLiteral(t *testing.T) {
+	p := New()
+	defer p.Close()
+	var out strings.Builder
+	p.EnablePrompt(strings.NewReader("env:HOME\n-\n"), &out)
+
+	var tok Secret
+	var pw string
+	p.ArgOption(&tok, 0, "token", "TOKEN", "").MustSet()
+	p.ArgOption(&pw, 0, "password-file", "PATH", "").FromFile().MustSet()
+	if _, err := p.Parse([]string{"prog"}); err != nil {
+		t.Fatalf("%v\n%s", err, out.String())
+	}
+	if string(tok.Bytes()) != "env:HOME" || pw != "-" {
+		t.Errorf("token %q, password %q; want the answers as typed", tok.Bytes(), pw)
+	}
+}
+
+func TestPromptSkippedWhenSet(t *testing.T) {
+	p := New()
+	defer p.Close()
+	var out strings.Builder
+	p.EnablePrompt(strings.NewReader(""), &out)
+
+	var name string
+	p.ArgOption(&name, 'n', "name", "NAME", "").MustSet()
+	if _, err := p.Parse([]string{"prog", "-n", "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("prompted although set:\n%s", out.String())
+	}
+
+	// End of input falls back to the usual error.
+	if _, err := p.Parse([]string{"prog"}); err == nil || !strings.Contains(err.Error(), "not given") {
+		t.Errorf("err = %v; want 'not given'", err)
+	}
+}
+
+func TestPromptNotTerminal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "in")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.WriteString("bob\n")
+	f.Seek(0, 0)
+
+	p := New()
+	defer p.Close()
+	var out strings.Builder
+	p.EnablePrompt(f, &out)
+	var name string
+	p.ArgOption(&name, 'n', "name", "NAME", "").MustSet()
+	if _, err = p.Parse([]string{"prog"}); err == nil {
+		t.Errorf("prompted on a regular file; name = %q", name)
+	}
+}
+
+func TestChoices(t *testing.T) {
+	p := New()
+	defer p.Close()
+	var out strings.Builder
+	p.SetOutput(&out)
+
+	level := "info"
+	p.ArgOption(&level, 'l', "level", "LEVEL", "Log level").Choices("debug", "info")
+	if _, err := p.Parse([]string{"prog", "-l", "trace"}); err == nil {
+		t.Error("expected error for value outside choices")
+	}
+	if _, err := p.Parse([]string{"prog", "--level=debug"}); err != nil || level != "debug" {
+		t.Errorf("err = %v, level = %q", err, level)
+	}
+	if _, err := p.Parse([]string{"prog"}); err != nil || level != "info" {
+		t.Errorf("err = %v, level = %q; want default restored", err, level)
+	}
+	p.HelpCommand(nil, false)
+	if !strings.Contains(out.String(), "(one of: debug, info)") {
+		t.Errorf("help lacks choices:\n%s", out.String())
+	}
+}
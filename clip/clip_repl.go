@@ -0,0 +1,139 @@
+package clip
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// REPL runs an interactive shell over p's command tree.  Each line read from
+// in is split with [SplitCommandLine], parsed as if it followed the program
+// name on the command line, and run with [Command.Run].  As Parse resets
+// options and Arguments on every call, nothing carries over from one line to
+// the next.  Errors are reported on out and the shell carries on.
+//
+// Besides the registered sub-commands, the shell understands:
+//
+//	help [COMMAND...]   print help for the root or the given sub-command
+//	history             list the lines entered so far
+//	!N, !!              re-run history line N, or the last line
+//	exit, quit          leave the shell
+//
+// A registered sub-command with the same name as a built-in takes
+// precedence.  Help and prompts are written to out for the duration of the
+// call.  REPL returns nil on exit or end of input, or the error from reading
+// in.
+func (p *Parser) REPL(in io.Reader, out io.Writer) error {
+	saved := p.out
+	p.out = out
+	defer func() { p.out = saved }()
+	defer p.Close()
+
+	prompt := p.progName() + "> "
+	var history []string
+	sc := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, prompt)
+		if !sc.Scan() {
+			fmt.Fprintln(out)
+			return sc.Err()
+		}
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "!") {
+			var err error
+			if line, err = historyLine(history, line); err != nil {
+				fmt.Fprintln(out, p.localize(err))
+				continue
+			}
+			fmt.Fprintln(out, line)
+		}
+		if line == "" {
+			continue
+		}
+		history = append(history, line)
+
+		toks, err := SplitCommandLine(line)
+		if err != nil {
+			fmt.Fprintln(out, p.localize(err))
+			continue
+		}
+		if len(toks) == 0 {
+			continue
+		}
+		if !p.hasSubCommand(toks[0]) {
+			switch toks[0] {
+			case "exit", "quit":
+				return nil
+			case "help":
+				p.replHelp(out, toks[1:])
+				continue
+			case "history":
+				for i, h := range history {
+					fmt.Fprintf(out, "%5d  %s\n", i+1, h)
+				}
+				continue
+			}
+		}
+
+		cmd, err := p.parseTokens(toks)
+		if errors.Is(err, ErrHelp) || errors.Is(err, ErrVersion) {
+			continue
+		}
+		if err == nil {
+			err = cmd.Run()
+		}
+		if err != nil {
+			fmt.Fprintln(out, err)
+		}
+	}
+}
+
+// historyLine resolves the history reference ref ("!!" or "!N").
+func historyLine(history []string, ref string) (string, error) {
+	if ref == "!!" {
+		if len(history) == 0 {
+			return "", msgf("%s: event not found", ref)
+		}
+		return history[len(history)-1], nil
+	}
+	n, err := strconv.Atoi(ref[1:])
+	if err != nil || n < 1 || n > len(history) {
+		return "", msgf("%s: event not found", ref)
+	}
+	return history[n-1], nil
+}
+
+func (p *Parser) hasSubCommand(name string) bool {
+	for _, sc := range p.subcmds {
+		if sc.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// replHelp prints help to out for the sub-command named by path, or for the
+// root followed by the shell's built-ins if path is empty.
+func (p *Parser) replHelp(out io.Writer, path []string) {
+	c := &p.Command
+	for _, name := range path {
+		_, sc, err := parseSubCommand(c, name)
+		if err != nil || sc == nil {
+			fmt.Fprintf(out, p.tr("help: no command '%s'")+"\n", strings.Join(path, " "))
+			return
+		}
+		c = sc
+	}
+	p.HelpCommand(c, false)
+	if c == &p.Command {
+		prtList(out, [][2]string{
+			{"  help [COMMAND...]", p.tr("Show help")},
+			{"  history", p.tr("List previous lines; !N or !! re-runs one")},
+			{"  exit", p.tr("Leave the shell")},
+		}, p.tr("Shell Commands"))
+		fmt.Fprintln(out)
+	}
+}
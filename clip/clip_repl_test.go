@@ -0,0 +1,95 @@
+package clip
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newREPLParser(greeted *[]string) *Parser {
+	p := New()
+	p.Name = "admin"
+	p.SetLogBufSize(16)
+	greet := p.SubCommand("greet", "Say hello", "")
+	name := "world"
+	var loud bool
+	greet.ArgOption(&name, 'n', "name", "NAME", "Who to greet")
+	greet.FlagOption(&loud, 'l', "loud", "")
+	greet.SetRuns(func(c *Command) error {
+		c.Logf("greeting %s", name)
+		s := "hello " + name
+		if loud {
+			s = strings.ToUpper(s)
+		}
+		*greeted = append(*greeted, s)
+		return nil
+	}, nil, nil)
+	return p
+}
+
+func TestREPLResetsStateBetweenLines(t *testing.T) {
+	var greeted []string
+	p := newREPLParser(&greeted)
+	var out bytes.Buffer
+
+	in := strings.NewReader("greet -n bob -l\ngreet\ngreet --name 'al ice'\n")
+	if err := p.REPL(in, &out); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"HELLO BOB", "hello world", "hello al ice"}
+	if !slices.Equal(greeted, want) {
+		t.Errorf("greeted = %q; want %q\n%s", greeted, want, out.String())
+	}
+}
+
+func TestREPLHistory(t *testing.T) {
+	var greeted []string
+	p := newREPLParser(&greeted)
+	var out bytes.Buffer
+
+	in := strings.NewReader("greet -n a\ngreet -n b\nhistory\n!1\n!!\n!9\n")
+	if err := p.REPL(in, &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"hello a", "hello b", "hello a", "hello a"}; !slices.Equal(greeted, want) {
+		t.Errorf("greeted = %q; want %q", greeted, want)
+	}
+	for _, s := range []string{"    1  greet -n a", "    3  history", "!9: event not found"} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("output lacks %q:\n%s", s, out.String())
+		}
+	}
+}
+
+func TestREPLHelpErrorsAndExit(t *testing.T) {
+	var greeted []string
+	p := newREPLParser(&greeted)
+	var out bytes.Buffer
+
+	in := strings.NewReader("help\nhelp greet\nhelp nope\nbogus\ngreet --oops\ngreet -h\nexit\ngreet\n")
+	if err := p.REPL(in, &out); err != nil {
+		t.Fatal(err)
+	}
+	s := out.String()
+	for _, want := range []string{"admin> ", "Shell Commands:", "--name <NAME>", "help: no command 'nope'", "'bogus' not recognized", "'oops' not recognized"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("output lacks %q:\n%s", want, s)
+		}
+	}
+	if len(greeted) != 0 {
+		t.Errorf("greeted = %q; nothing should run after exit or on errors", greeted)
+	}
+}
+
+func TestREPLSubCommandShadowsBuiltin(t *testing.T) {
+	p := New()
+	ran := false
+	p.SubCommand("history", "", "").SetRuns(func(c *Command) error { ran = true; return nil }, nil, nil)
+	if err := p.REPL(strings.NewReader("history\n"), &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	if !ran {
+		t.Error("registered history sub-command should win over the built-in")
+	}
+}
@@ -0,0 +1,85 @@
+package clip
+
+import "slices"
+
+// Source tells where the value of an option came from.
+type Source int
+
+// Sources, from lowest to highest precedence: a value given on the command
+// line beats one from the environment, and so on down to the default.
+const (
+	SourceDefault      Source = iota // not given; the variable keeps its initial value
+	SourceProgrammatic               // set by the program with Option.SetValue
+	SourceConfig                     // taken from the values passed to Parser.SetConfig
+	SourceEnv                        // taken from the variable named by Option.Env
+	SourceCommandLine                // given on the command line
+
+	// SourcePrompt means the user typed the value at a prompt (see
+	// Parser.EnablePrompt), which only happens when no other source has one.
+	SourcePrompt
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceDefault:
+		return "default"
+	case SourceProgrammatic:
+		return "programmatic"
+	case SourceConfig:
+		return "config"
+	case SourceEnv:
+		return "env"
+	case SourceCommandLine:
+		return "command line"
+	case SourcePrompt:
+		return "prompt"
+	}
+	return "unknown"
+}
+
+// Result describes one successful [Parser.ParseResult]: the options that
+// were set, the command path that was matched and the remaining arguments.
+// It is a snapshot; parsing again with the same Parser does not change it.
+type Result struct {
+	p    *Parser
+	set  map[*Option]Source
+	path []*Command
+	args []string
+
+	printConfig string // format requested with --print-config, if any
+}
+
+// mark records that o got its value from s.
+func (r *Result) mark(o *Option, s Source) {
+	r.set[o] = s
+	o.src = s
+}
+
+// IsSet reports whether o was given a value during the parse, from any
+// source other than its default.  A repeatable option counts as set once it
+// has been given at least once.
+func (r *Result) IsSet(o *Option) bool {
+	_, ok := r.set[o]
+	return ok
+}
+
+// Source returns where the value of o came from.  Options that were not set
+// report SourceDefault.
+func (r *Result) Source(o *Option) Source {
+	if s, ok := r.set[o]; ok {
+		return s
+	}
+	return SourceDefault
+}
+
+// Arguments returns the arguments left over for the matched command, as
+// Command.Arguments held them right after the parse.
+func (r *Result) Arguments() []string { return slices.Clone(r.args) }
+
+// Path returns the matched command path, from the root command to the
+// sub-command that was selected, e.g. [root, remote, add] for
+// "prog remote add".
+func (r *Result) Path() []*Command { return slices.Clone(r.path) }
+
+// Command returns the matched command, i.e. the last element of Path.
+func (r *Result) Command() *Command { return r.path[len(r.path)-1] }
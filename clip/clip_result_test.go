@@ -0,0 +1,94 @@
+package clip
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseResult(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var verbose bool
+	var name, url string
+	v := p.FlagOption(&verbose, 'v', "verbose", "").Persistent()
+	remote := p.SubCommand("remote", "", "")
+	add := remote.SubCommand("add", "", "")
+	n := add.ArgOption(&name, 'n', "name", "NAME", "")
+	u := add.Positional(&url, "URL", "")
+
+	r, err := p.ParseResult([]string{"prog", "remote", "add", "-v", "https://x", "--", "a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Path(); !slices.Equal(got, []*Command{&p.Command, remote, add}) {
+		t.Errorf("Path() = %v", got)
+	}
+	if r.Command() != add {
+		t.Errorf("Command() = %q; want add", r.Command().Name)
+	}
+	if !r.IsSet(v) || !r.IsSet(u) || r.IsSet(n) {
+		t.Errorf("IsSet: verbose=%v URL=%v name=%v", r.IsSet(v), r.IsSet(u), r.IsSet(n))
+	}
+	if r.Source(v) != SourceCommandLine || r.Source(n) != SourceDefault {
+		t.Errorf("Source: verbose=%v name=%v", r.Source(v), r.Source(n))
+	}
+	if got := r.Arguments(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Arguments() = %q", got)
+	}
+}
+
+func TestParseRepeatedly(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	port := 80
+	o := p.ArgOption(&port, 'p', "port", "PORT", "")
+
+	r1, err := p.ParseResult([]string{"prog", "-p", "8080", "--", "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 8080 {
+		t.Errorf("port = %d; want 8080", port)
+	}
+
+	// A second parse must not see the first one's option or Arguments.
+	r2, err := p.ParseResult([]string{"prog", "--port", "9090"})
+	if err != nil {
+		t.Fatalf("second parse: %v", err)
+	}
+	if port != 9090 || p.Arguments != nil {
+		t.Errorf("port = %d, Arguments = %q; want 9090, nil", port, p.Arguments)
+	}
+
+	if _, err = p.ParseResult([]string{"prog"}); err != nil {
+		t.Fatal(err)
+	}
+	if port != 80 {
+		t.Errorf("port = %d after parse without -p; want 80", port)
+	}
+
+	// Earlier results are unaffected by later parses.
+	if !r1.IsSet(o) || !slices.Equal(r1.Arguments(), []string{"x"}) {
+		t.Errorf("r1: IsSet = %v, Arguments = %q", r1.IsSet(o), r1.Arguments())
+	}
+	if !r2.IsSet(o) || len(r2.Arguments()) != 0 {
+		t.Errorf("r2: IsSet = %v, Arguments = %q", r2.IsSet(o), r2.Arguments())
+	}
+}
+
+func TestParseRepeatedlyMustSet(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var name string
+	p.ArgOption(&name, 'n', "name", "NAME", "").MustSet()
+
+	if _, err := p.Parse([]string{"prog", "-n", "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Parse([]string{"prog"}); err == nil {
+		t.Error("expected 'not given' error on second parse")
+	}
+}
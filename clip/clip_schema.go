@@ -0,0 +1,395 @@
+package clip
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// schemaCommand is the JSON form of a command; see Parser.Schema.
+type schemaCommand struct {
+	Name            string          `json:"name"`
+	Description     string          `json:"description,omitempty"`
+	LongDescription string          `json:"longDescription,omitempty"`
+	Version         string          `json:"version,omitempty"` // root only
+	Hidden          bool            `json:"hidden,omitempty"`
+	Arguments       string          `json:"arguments,omitempty"`
+	Examples        []schemaExample `json:"examples,omitempty"`
+	Options         []schemaOption  `json:"options,omitempty"`
+	Positionals     []schemaOption  `json:"positionals,omitempty"`
+	Commands        []schemaCommand `json:"commands,omitempty"`
+}
+
+type schemaExample struct {
+	Command     string `json:"command"`
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// schemaOption is the JSON form of an option or positional.
+type schemaOption struct {
+	Name        string   `json:"name,omitempty"`
+	Short       string   `json:"short,omitempty"`
+	Kind        string   `json:"kind,omitempty"` // "flag", "count" or "arg"; positionals have none
+	Type        string   `json:"type"`
+	ArgName     string   `json:"argName,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	Separator   string   `json:"separator,omitempty"` // between the elements of a list default
+	Layouts     []string `json:"layouts,omitempty"`   // see TimeLayout
+	Implicit    *string  `json:"implicit,omitempty"`
+	Arity       string   `json:"arity,omitempty"`
+	Step        int      `json:"step,omitempty"`
+	Choices     []string `json:"choices,omitempty"`
+	Suggest     string   `json:"suggest,omitempty"`
+	Env         string   `json:"env,omitempty"`
+	Group       string   `json:"group,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Hidden      bool     `json:"hidden,omitempty"`
+	Persistent  bool     `json:"persistent,omitempty"`
+	Repeatable  bool     `json:"repeatable,omitempty"`
+	Reverse     bool     `json:"reverse,omitempty"`
+	Secret      bool     `json:"secret,omitempty"`
+	FromFile    bool     `json:"fromFile,omitempty"`
+}
+
+// schemaTypes holds a pointer to each type optConv accepts, and to Secret
+// and ByteSize.
+// Options of these types can be rebuilt by FromSchema without a binding.
+var schemaTypes = []interface{}{
+	new(bool), new(int), new(int8), new(int16), new(int32), new(int64),
+	new(uint), new(uint8), new(uint16), new(uint32), new(uint64),
+	new(float32), new(float64), new(string),
+	new(time.Duration), new(time.Time), new(net.IP), new(net.IPNet),
+	new(net.HardwareAddr), new(netip.Prefix), new(netip.AddrPort),
+	new(url.URL), new(*url.URL), new(*regexp.Regexp), new(os.FileMode),
+	new(big.Int), new(*big.Int), new(big.Float), new(*big.Float),
+	new([]string), new([]int), new([]int64), new([]uint), new([]uint64),
+	new([]float64), new([]time.Duration), new([]net.IP),
+	new(Secret), new(ByteSize),
+}
+
+// typeNames maps the wrapper optConv returns to the name of the wrapped type;
+// typesByName maps the name back to the type.
+var typeNames, typesByName = func() (map[reflect.Type]string, map[string]reflect.Type) {
+	names := map[reflect.Type]string{reflect.TypeOf(&clipTimeLayout{}): "time.Time"}
+	types := map[string]reflect.Type{}
+	for _, v := range schemaTypes {
+		t := reflect.TypeOf(v).Elem()
+		names[reflect.TypeOf(optConv(v))] = t.String()
+		types[t.String()] = t
+	}
+	return names, types
+}()
+
+// Schema returns a JSON description of p's command tree, for generating
+// wrappers, documentation or forms.  Each command has its name,
+// descriptions, examples, options, positionals and sub-commands; each option
+// its names, kind ("flag", "count" or "arg"), Go type, default, and the
+// settings made with the Option methods, such as "required" for MustSet.
+// Built-in types are named as in Go source ("int", "time.Duration",
+// "[]string"); other types by their package and name.  A [TimeLayout] option
+// is a "time.Time" with its layouts, and a list default comes with the
+// separator between its elements.  Secret defaults are left out, and the help
+// and version options are implied.  Plugins are not
+// run, so their descriptions are empty unless help has been printed.
+//
+// Defaults are the values of the bound variables before the first Parse.
+func (p *Parser) Schema() ([]byte, error) {
+	root := p.schemaCommand(&p.Command)
+	root.Name = p.shownName()
+	root.Description = p.progInfo
+	if slices.Contains(p.Command.opts, &p.versionOption) {
+		root.Version = p.versionString()
+	}
+	return json.MarshalIndent(root, "", "  ")
+}
+
+func (p *Parser) schemaCommand(c *Command) schemaCommand {
+	sc := schemaCommand{
+		Name: c.Name, Description: c.desc, LongDescription: c.longDesc,
+		Hidden: c.hide, Arguments: c.argsName,
+	}
+	for _, e := range c.examples {
+		sc.Examples = append(sc.Examples, schemaExample{e[0], e[1]})
+	}
+	for _, o := range c.opts {
+		if o != &p.helpOption && o != &p.versionOption {
+			sc.Options = append(sc.Options, schemaOpt(o))
+		}
+	}
+	for _, o := range c.positionals {
+		sc.Positionals = append(sc.Positionals, schemaOpt(o))
+	}
+	for _, s := range c.subcmds {
+		sc.Commands = append(sc.Commands, p.schemaCommand(s))
+	}
+	return sc
+}
+
+func schemaOpt(o *Option) schemaOption {
+	v, fromFile := unwrapValue(o.v)
+	so := schemaOption{
+		Name: o.longName, Type: typeName(v), ArgName: o.argName, Description: o.desc,
+		Choices: o.choices, Suggest: o.suggest, Env: o.env, Group: o.group,
+		Required: o.mustSet, Hidden: o.hide, Persistent: o.persistent,
+		Repeatable: o.repeatable, Reverse: o.reverseFlag, Secret: o.secret,
+		FromFile: fromFile,
+	}
+	if o.shortName != 0 {
+		so.Short = string(o.shortName)
+	}
+	if !o.secret {
+		so.Default = o.defaultString()
+		if so.Default != "" {
+			so.Separator = o.defaultSep()
+		}
+	}
+	if t, ok := v.(*clipTimeLayout); ok {
+		so.Layouts = t.layouts
+	}
+	switch {
+	case o.pos:
+		so.Arity = string(o.arity)
+	case o.incrStep != 0:
+		so.Kind, so.Step = "count", o.incrStep
+	case o.hasArg:
+		so.Kind = "arg"
+		if o.optArg {
+			implicit := o.implicit
+			so.Implicit = &implicit
+		}
+	default:
+		so.Kind = "flag"
+	}
+	return so
+}
+
+// unwrapValue returns the value inside the wrappers added by Choices and
+// FromFile, and whether FromFile was one of them.
+func unwrapValue(v IOption) (_ IOption, fromFile bool) {
+	for {
+		switch w := v.(type) {
+		case *choiceValue:
+			v = w.IOption
+		case *fileValue:
+			v, fromFile = w.IOption, true
+		default:
+			return v, fromFile
+		}
+	}
+}
+
+// typeName returns the name Schema gives to the type of v.
+func typeName(v IOption) string {
+	if n, ok := v.(interface{ typeName() string }); ok {
+		return n.typeName()
+	}
+	t := reflect.TypeOf(v)
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return strings.TrimPrefix(t.String(), "*")
+}
+
+// FromSchema builds a Parser from a document produced by [Parser.Schema].
+// bindings supplies the variables parsed values are stored in, keyed like
+// [Parser.SetConfig]: "verbose", "serve.port", or a positional's name.  A
+// short-only option is keyed by "-" and its letter, as in "serve.-p".  A flag
+// needs a *bool and a count an *int; other options take anything
+// [Command.ArgOption] accepts.  An option without a binding gets a variable
+// of its own, initialised to the schema's default, if its type is built in;
+// otherwise FromSchema fails.  A bound variable keeps its value.
+//
+// Run functions cannot be described in a schema; set them with
+// [Command.SetRuns] on the command [Result.Command] returns.
+func FromSchema(data []byte, bindings map[string]any) (*Parser, error) {
+	var root schemaCommand
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+	p := New()
+	p.Name = root.Name
+	p.ProgDescription(root.Description)
+	if root.Version != "" {
+		p.Version(root.Version)
+	}
+	used := make(map[string]bool)
+	if err := buildCommand(&p.Command, root, "", bindings, used); err != nil {
+		return nil, err
+	}
+	for _, key := range slices.Sorted(maps.Keys(bindings)) {
+		if !used[key] {
+			return nil, fmt.Errorf("schema: binding %q matches no option", key)
+		}
+	}
+	return p, nil
+}
+
+func buildCommand(c *Command, sc schemaCommand, prefix string, bindings map[string]any, used map[string]bool) error {
+	if len(sc.Positionals) > 0 && len(sc.Commands) > 0 {
+		return fmt.Errorf("schema: command %q has both positionals and sub-commands", sc.Name)
+	}
+	if sc.Hidden {
+		c.Hide()
+	}
+	if sc.Arguments != "" {
+		c.AcceptArguments(sc.Arguments)
+	}
+	for _, e := range sc.Examples {
+		c.Example(e.Command, e.Explanation)
+	}
+	for _, so := range sc.Options {
+		if err := buildOption(c, so, prefix, false, bindings, used); err != nil {
+			return err
+		}
+	}
+	for _, so := range sc.Positionals {
+		if err := buildOption(c, so, prefix, true, bindings, used); err != nil {
+			return err
+		}
+	}
+	for _, s := range sc.Commands {
+		sub := c.SubCommand(s.Name, s.Description, s.LongDescription)
+		if err := buildCommand(sub, s, prefix+s.Name+".", bindings, used); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func buildOption(c *Command, so schemaOption, prefix string, pos bool, bindings map[string]any, used map[string]bool) error {
+	key := prefix + so.Name
+	if so.Name == "" {
+		key = prefix + "-" + so.Short
+	}
+	if len(so.Short) > 1 {
+		return fmt.Errorf("schema: %s: short name %q is longer than one character", key, so.Short)
+	}
+	var short byte
+	if so.Short != "" {
+		short = so.Short[0]
+	}
+	v, bound := bindings[key]
+	if bound {
+		used[key] = true
+	} else if t, ok := typesByName[so.Type]; ok {
+		v = reflect.New(t).Interface()
+	} else {
+		return fmt.Errorf("schema: %s: type %s is not built in; bind a variable to it", key, so.Type)
+	}
+	if len(so.Layouts) > 0 {
+		t, ok := v.(*time.Time)
+		if !ok {
+			return fmt.Errorf("schema: %s: layouts need a *time.Time, not %T", key, v)
+		}
+		v = TimeLayout(t, so.Layouts...)
+	}
+
+	var o *Option
+	switch {
+	case pos:
+		iv, err := convert(v)
+		if err != nil {
+			return fmt.Errorf("schema: %s: %v", key, err)
+		}
+		o = c.PositionalCustom(iv, so.Name, so.Description)
+		if so.Arity != "" {
+			if len(so.Arity) != 1 || !strings.Contains("1?+*", so.Arity) {
+				return fmt.Errorf("schema: %s: invalid arity %q", key, so.Arity)
+			}
+			o.Arity(so.Arity[0])
+		}
+	case so.Kind == "flag":
+		b, ok := v.(*bool)
+		if !ok {
+			return fmt.Errorf("schema: %s: a flag needs a *bool, not %T", key, v)
+		}
+		o = c.FlagOption(b, short, so.Name, so.Description)
+		if so.Reverse {
+			o.ReverseFlag()
+		}
+	case so.Kind == "count":
+		n, ok := v.(*int)
+		if !ok {
+			return fmt.Errorf("schema: %s: a count needs an *int, not %T", key, v)
+		}
+		o = c.IncrOption(n, short, so.Name, so.Description)
+		if so.Step != 0 {
+			o.SetIncrStep(so.Step)
+		}
+	case so.Kind == "arg":
+		iv, err := convert(v)
+		if err != nil {
+			return fmt.Errorf("schema: %s: %v", key, err)
+		}
+		o = c.ArgOptionCustom(iv, short, so.Name, so.ArgName, so.Description)
+		if so.Implicit != nil {
+			o.Implicit(*so.Implicit)
+		}
+	default:
+		return fmt.Errorf("schema: %s: unknown kind %q", key, so.Kind)
+	}
+
+	// The default goes in before Choices and FromFile wrap the value.
+	if !bound && so.Default != "" {
+		defaults := []string{so.Default}
+		if reflect.TypeOf(v).Elem().Kind() == reflect.Slice {
+			sep := so.Separator
+			if sep == "" {
+				sep = ","
+			}
+			defaults = strings.Split(so.Default, sep)
+		}
+		for _, s := range defaults {
+			if err := o.v.Parse(s); err != nil {
+				return fmt.Errorf("schema: %s: default: %v", key, err)
+			}
+		}
+	}
+	if (len(so.Choices) > 0 || so.FromFile) && !o.hasArg && !pos {
+		return fmt.Errorf("schema: %s: choices and fromFile need an argument", key)
+	}
+	if len(so.Choices) > 0 {
+		o.Choices(so.Choices...)
+	}
+	if so.FromFile {
+		o.FromFile()
+	}
+	if so.Group != "" && !pos {
+		o.Group(so.Group)
+	}
+	if so.Persistent && !pos {
+		o.Persistent()
+	}
+	o.Suggest(so.Suggest).Env(so.Env).Repeatable(so.Repeatable || o.incrStep != 0)
+	if so.Required {
+		o.MustSet()
+	}
+	if so.Hidden {
+		o.Hide()
+	}
+	if so.Secret {
+		o.Secret()
+	}
+	return nil
+}
+
+// convert is optConv reporting an unsupported type as an error.
+func convert(v interface{}) (iv IOption, err error) {
+	defer func() {
+		if recover() != nil {
+			err = fmt.Errorf("unsupported variable type %T", v)
+		}
+	}()
+	return optConv(v), nil
+}
@@ -0,0 +1,200 @@
+package clip
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSchemaParser() *Parser {
+	var (
+		verbose int
+		dry     bool
+		timeout = 30 * time.Second
+		mode    = "fast"
+		tags    []string
+		tok     Secret
+		lvl     level
+		port    = 8080
+		srcs    []string
+		dst     string
+	)
+	p := New().Version("2.0")
+	p.Name = "tool"
+	p.ProgDescription("Does things")
+	p.IncrOption(&verbose, 'v', "verbose", "More output").Persistent()
+	p.FlagOption(&dry, 'n', "dry-run", "").Env("TOOL_DRY")
+	p.ArgOption(&timeout, 0, "timeout", "DUR", "").Group("Network")
+	p.ArgOption(&mode, 'm', "", "MODE", "").Choices("fast", "slow").Implicit("fast")
+	p.ArgOption(&tags, 't', "tag", "TAG", "").Repeatable(true).Hide()
+	p.ArgOption(&tok, 0, "token", "TOKEN", "").FromFile()
+	Arg(&p.Command, &lvl, 'l', "level", "N", "", parseLevel)
+	serve := p.SubCommand("serve", "Start the server", "").Example("tool serve -p 80", "Serve on port 80")
+	serve.ArgOption(&port, 'p', "port", "PORT", "").MustSet()
+	cp := p.SubCommand("cp", "Copy", "").AcceptArguments("MORE")
+	cp.Positional(&srcs, "src", "").Arity(ArityOneOrMore)
+	cp.Positional(&dst, "dst", "")
+	return p
+}
+
+func decodeSchema(t *testing.T, data []byte) schemaCommand {
+	t.Helper()
+	var sc schemaCommand
+	if err := json.Unmarshal(data, &sc); err != nil {
+		t.Fatal(err)
+	}
+	return sc
+}
+
+func TestSchema(t *testing.T) {
+	p := newSchemaParser()
+	defer p.Close()
+	data, err := p.Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := decodeSchema(t, data)
+	if sc.Name != "tool" || sc.Description != "Does things" || sc.Version != "2.0" {
+		t.Errorf("root = %q, %q, %q", sc.Name, sc.Description, sc.Version)
+	}
+	want := []struct{ name, kind, typ, def string }{
+		{"verbose", "count", "int", "0"},
+		{"dry-run", "flag", "bool", "false"},
+		{"timeout", "arg", "time.Duration", "30s"},
+		{"", "arg", "string", "fast"},
+		{"tag", "arg", "[]string", ""},
+		{"token", "arg", "clip.Secret", ""},
+		{"level", "arg", "clip.level", "0"},
+	}
+	if len(sc.Options) != len(want) {
+		t.Fatalf("%d options, want %d (help and version are implied):\n%s", len(sc.Options), len(want), data)
+	}
+	for i, w := range want {
+		o := sc.Options[i]
+		if o.Name != w.name || o.Kind != w.kind || o.Type != w.typ || o.Default != w.def {
+			t.Errorf("option %d = %q %s %s %q; want %q %s %s %q", i, o.Name, o.Kind, o.Type, o.Default, w.name, w.kind, w.typ, w.def)
+		}
+	}
+	o := sc.Options
+	if !o[0].Persistent || o[0].Step != 1 || o[1].Env != "TOOL_DRY" || o[2].Group != "Network" ||
+		o[3].Short != "m" || strings.Join(o[3].Choices, ",") != "fast,slow" || o[3].Implicit == nil ||
+		!o[4].Hidden || !o[4].Repeatable || !o[5].Secret || !o[5].FromFile {
+		t.Errorf("option settings lost:\n%s", data)
+	}
+	serve, cp := sc.Commands[0], sc.Commands[1]
+	if !serve.Options[0].Required || len(serve.Examples) != 1 || serve.Examples[0].Explanation != "Serve on port 80" {
+		t.Errorf("serve = %+v", serve)
+	}
+	if cp.Arguments != "MORE" || len(cp.Positionals) != 2 || cp.Positionals[0].Arity != "+" || cp.Positionals[1].Arity != "1" {
+		t.Errorf("cp = %+v", cp)
+	}
+}
+
+func TestFromSchemaRoundTrip(t *testing.T) {
+	p := newSchemaParser()
+	defer p.Close()
+	data, err := p.Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lvl level
+	q, err := FromSchema(data, map[string]any{"level": NewValue(&lvl, parseLevel)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	again, err := q.Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("schema changed in the round trip:\n--- got\n%s\n--- want\n%s", again, data)
+	}
+}
+
+func TestSchemaBuiltinTypes(t *testing.T) {
+	p := New()
+	defer p.Close()
+	limit := 4 * MiB
+	since := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	filters := []string{"a,b", "c"}
+	p.ArgOption(&limit, 0, "limit", "SIZE", "")
+	p.ArgOptionCustom(TimeLayout(&since, "2006-01-02"), 0, "since", "DATE", "")
+	p.ArgOption(&filters, 0, "filter", "EXPR", "").Repeatable(true)
+	data, _ := p.Schema()
+	q, err := FromSchema(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if again, _ := q.Schema(); string(again) != string(data) {
+		t.Errorf("schema changed in the round trip:\n--- got\n%s\n--- want\n%s", again, data)
+	}
+	sc := decodeSchema(t, data)
+	if o := sc.Options[1]; o.Default != "2024-01-02" || strings.Join(o.Layouts, "|") != "2006-01-02" {
+		t.Errorf("since = %q with layouts %q", o.Default, o.Layouts)
+	}
+	if o := sc.Options[2]; o.Default != "a,b;c" || o.Separator != ";" {
+		t.Errorf("filter = %q separated by %q", o.Default, o.Separator)
+	}
+	if _, err := q.Parse([]string{"prog", "--since", "2025-03-04"}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSchemaPlugins(t *testing.T) {
+	p, _ := newPluginParser(t)
+	defer p.Close()
+	if _, err := p.Schema(); err != nil {
+		t.Fatal(err)
+	}
+	if greet := p.subcmds[1]; greet.plugin.described {
+		t.Error("Schema ran the plugin to describe it")
+	}
+}
+
+func TestFromSchemaBindings(t *testing.T) {
+	p := newSchemaParser()
+	defer p.Close()
+	data, _ := p.Schema()
+
+	var (
+		lvl   level
+		port  int
+		files []string
+		dry   bool
+	)
+	q, err := FromSchema(data, map[string]any{
+		"level":      NewValue(&lvl, parseLevel),
+		"dry-run":    &dry,
+		"serve.port": &port,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if _, err := q.Parse([]string{"tool", "-n", "--level", "high", "serve", "-p", "80"}); err != nil {
+		t.Fatal(err)
+	}
+	if !dry || lvl != 2 || port != 80 {
+		t.Errorf("dry %v, level %d, port %d", dry, lvl, port)
+	}
+	if _, err := q.Parse([]string{"tool", "serve"}); err == nil {
+		t.Error("required port was not enforced")
+	}
+
+	for _, tt := range []struct {
+		bindings map[string]any
+		want     string
+	}{
+		{nil, "schema: level: type clip.level is not built in; bind a variable to it"},
+		{map[string]any{"level": &lvl}, "schema: level: unsupported variable type *clip.level"},
+		{map[string]any{"level": NewValue(&lvl, parseLevel), "cp.files": &files}, `schema: binding "cp.files" matches no option`},
+		{map[string]any{"level": NewValue(&lvl, parseLevel), "dry-run": &port}, "schema: dry-run: a flag needs a *bool, not *int"},
+	} {
+		if _, err := FromSchema(data, tt.bindings); errString(err) != tt.want {
+			t.Errorf("FromSchema err = %v; want %q", err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package clip
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// Secret is an option type for passwords, tokens and other credentials.  Its
+// argument names where to read the value from, so the value itself need not
+// appear in argv, where other users can see it with ps:
+//
+//	"-"           read standard input to the end
+//	env:NAME      the environment variable NAME, which must be set
+//	file:PATH     the contents of the file PATH
+//	anything else the argument itself
+//
+// A single trailing newline is dropped from stdin and file contents.  Pass a
+// *Secret to [Command.ArgOption]; options of this type behave as if
+// [Option.Secret] had been called, and their parse errors never include the
+// value.  Call Zero once the value is no longer needed.
+type Secret struct {
+	b []byte
+}
+
+// Bytes returns the value.  The slice is the Secret's own storage, which Zero
+// overwrites.
+func (s *Secret) Bytes() []byte { return s.b }
+
+// Zero overwrites the value in memory and empties the Secret.
+func (s *Secret) Zero() {
+	clear(s.b)
+	s.b = nil
+}
+
+// String returns "****" if the Secret holds a value and "" otherwise, so the
+// value cannot leak through fmt or help text.
+func (s *Secret) String() string {
+	if len(s.b) == 0 {
+		return ""
+	}
+	return "****"
+}
+
+func (s *Secret) Parse(arg string) error {
+	switch {
+	case arg == "-":
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return msgf("reading secret from stdin: %v", err)
+		}
+		s.b = trimNewline(b)
+	case strings.HasPrefix(arg, "env:"):
+		v, ok := os.LookupEnv(arg[4:])
+		if !ok {
+			return msgf("environment variable %s is not set", arg[4:])
+		}
+		s.b = []byte(v)
+	case strings.HasPrefix(arg, "file:"):
+		b, err := os.ReadFile(arg[5:])
+		if err != nil {
+			return msgf("reading secret: %v", err)
+		}
+		s.b = trimNewline(b)
+	default:
+		s.b = []byte(arg)
+	}
+	return nil
+}
+
+func trimNewline(b []byte) []byte {
+	if n := len(b); n > 0 && b[n-1] == '\n' {
+		b = b[:n-1]
+		if n > 1 && b[n-2] == '\r' {
+			b = b[:n-2]
+		}
+	}
+	return b
+}
+
+// Secret keeps o's value out of help, --print-config and parse errors, e.g.
+// for tokens and passwords: help omits its default, --print-config prints
+// "****", and an invalid value is reported as "invalid value for --NAME",
+// without the value in any form.  See also the
+// [Secret] type, which keeps the value out of argv as well.
+func (o *Option) Secret() *Option {
+	o.secret = true
+	return o
+}
+
+// parse parses s into o's value.  If o is secret, the error is replaced
+// entirely, since the value may appear in it quoted or escaped.  The
+// argument of a FromFile option is a path, which fileValue reports itself,
+// and a Secret's errors never include the value.
+func (o *Option) parse(s string) error {
+	err := o.v.Parse(s)
+	switch o.v.(type) {
+	case *fileValue, *Secret:
+		return err
+	}
+	if err != nil && o.secret {
+		return o.secretError()
+	}
+	return err
+}
+
+// parseLiteral is like parse for an answer typed at a prompt, which is the
+// value itself: a Secret does not read "-", "env:" or "file:" references,
+// and a FromFile option does not read a file.
+func (o *Option) parseLiteral(s string) error {
+	v := o.v
+	if f, ok := v.(*fileValue); ok {
+		v = f.IOption
+	}
+	if sec, ok := v.(*Secret); ok {
+		sec.b = []byte(s)
+		return nil
+	}
+	if v == o.v {
+		return o.parse(s)
+	}
+	if v.Parse(s) != nil {
+		return o.secretError()
+	}
+	return nil
+}
+
+// secretError is the error for an invalid value of the secret option o.
+func (o *Option) secretError() error {
+	name := o.longName
+	if !o.pos {
+		name = o.displayName()
+	}
+	return msgf("invalid value for %s", name)
+}
+
+// FromFile makes o's argument the path of a file that holds the value, as
+// for "--password-file PATH".  A single trailing newline is dropped.  Such a
+// file usually holds a credential, so o is made [Option.Secret] as well: the
+// contents never appear in help, --print-config or parse errors.
+func (o *Option) FromFile() *Option {
+	if !o.hasArg && !o.pos {
+		panic("FromFile on Option without argument")
+	}
+	o.v = &fileValue{o.v}
+	o.secret = true
+	return o
+}
+
+// fileValue reads the value of a FromFile option from the named file.
+type fileValue struct{ IOption }
+
+func (f *fileValue) Parse(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	b = trimNewline(b)
+	if s, ok := f.IOption.(*Secret); ok {
+		s.b = b // the contents are the value, not a "-" or "env:" reference
+		return nil
+	}
+	if f.IOption.Parse(string(b)) != nil {
+		return msgf("invalid value in file %s", path)
+	}
+	return nil
+}
+
+func (f *fileValue) snapshot() func() { return snapshot(f.IOption) }
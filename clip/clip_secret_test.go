@@ -0,0 +1,153 @@
+package clip
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretSources(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tok")
+	if err := os.WriteFile(path, []byte("from-file\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TOKEN", "from-env")
+
+	tests := []struct{ arg, want string }{
+		{"literal", "literal"},
+		{"env:TOKEN", "from-env"},
+		{"file:" + path, "from-file"},
+	}
+	for _, tt := range tests {
+		var s Secret
+		if err := s.Parse(tt.arg); err != nil {
+			t.Errorf("Parse(%q): %v", tt.arg, err)
+		} else if string(s.Bytes()) != tt.want {
+			t.Errorf("Parse(%q) = %q; want %q", tt.arg, s.Bytes(), tt.want)
+		}
+	}
+
+	var s Secret
+	if err := s.Parse("env:CLIP_NO_SUCH_VAR"); err == nil {
+		t.Error("Parse(env:unset) succeeded")
+	}
+}
+
+func TestSecretStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteString("s3cret\n")
+	w.Close()
+	saved := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = saved }()
+
+	p := New()
+	defer p.Close()
+	var tok Secret
+	p.ArgOption(&tok, 't', "token", "TOKEN", "")
+	if _, err = p.Parse([]string{"prog", "--token", "-"}); err != nil {
+		t.Fatal(err)
+	}
+	if string(tok.Bytes()) != "s3cret" {
+		t.Errorf("token = %q; want s3cret", tok.Bytes())
+	}
+}
+
+func TestSecretZero(t *testing.T) {
+	var s Secret
+	s.Parse("hunter2")
+	b := s.Bytes()
+	s.Zero()
+	if !bytes.Equal(b, make([]byte, len(b))) || s.Bytes() != nil || s.String() != "" {
+		t.Errorf("after Zero: storage %q, Bytes %q, String %q", b, s.Bytes(), s.String())
+	}
+}
+
+func TestSecretNeverShown(t *testing.T) {
+	p := New()
+	defer p.Close()
+	var out bytes.Buffer
+	p.SetOutput(&out)
+
+	tok := Secret{b: []byte("default-token")}
+	port := 0
+	p.ArgOption(&tok, 't', "token", "TOKEN", "API token")
+	p.ArgOption(&port, 'p', "port", "PORT", "").Secret()
+
+	p.HelpCommand(nil, false)
+	if strings.Contains(out.String(), "default-token") || strings.Contains(out.String(), "****") {
+		t.Errorf("help shows secret default:\n%s", out.String())
+	}
+	out.Reset()
+	p.Parse([]string{"prog", "-t", "abc", "--print-config"})
+	if strings.Contains(out.String(), "abc") || !strings.Contains(out.String(), "token = ****") {
+		t.Errorf("--print-config shows secret:\n%s", out.String())
+	}
+	for _, arg := range []string{"--port=12ab", `--port=12"ab`, "--port=12\\ab"} {
+		if _, err := p.Parse([]string{"prog", arg}); errString(err) != "invalid value for -p/--port" {
+			t.Errorf("%s: err = %v; want a fixed message", arg, err)
+		}
+	}
+	useCatalog(t, "eo_ZZ", Catalog{"invalid value for %s": "nevalida valoro por %s"})
+	p.SetLocale("eo_ZZ")
+	if _, err := p.Parse([]string{"prog", "--port=12ab"}); errString(err) != "nevalida valoro por -p/--port" {
+		t.Errorf("err = %v; want it translated", err)
+	}
+}
+
+func TestFromFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, s string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(s), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	p := New()
+	defer p.Close()
+	var pw Secret
+	var n int
+	p.ArgOption(&pw, 0, "password-file", "PATH", "").FromFile()
+	p.ArgOption(&n, 0, "count-file", "PATH", "").FromFile()
+
+	// A "-" in the file is the password, not a request to read stdin.
+	if _, err := p.Parse([]string{"prog", "--password-file", write("pw", "-\n"), "--count-file", write("n", "7\n")}); err != nil {
+		t.Fatal(err)
+	}
+	if string(pw.Bytes()) != "-" || n != 7 {
+		t.Errorf("password = %q, count = %d", pw.Bytes(), n)
+	}
+
+	_, err := p.Parse([]string{"prog", "--count-file", write("bad", "x9z")})
+	if want := "invalid value in file " + filepath.Join(dir, "bad"); errString(err) != want {
+		t.Errorf("err = %v; want %q", err, want)
+	}
+	var out strings.Builder
+	p.SetOutput(&out)
+	var plain string
+	p.ArgOption(&plain, 0, "key-file", "PATH", "").FromFile()
+	if _, err := p.Parse([]string{"prog", "--key-file", write("key", "s3cr3t\n"), "--print-config"}); !errors.Is(err, ErrHelp) {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "s3cr3t") || !strings.Contains(out.String(), "key-file      = ****") {
+		t.Errorf("--print-config shows the file contents:\n%s", out.String())
+	}
+	out.Reset()
+	p.HelpCommand(nil, false)
+	if strings.Contains(out.String(), "s3cr3t") {
+		t.Errorf("help shows the file contents:\n%s", out.String())
+	}
+
+	_, err = p.Parse([]string{"prog", "--password-file", filepath.Join(dir, "missing")})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("err = %v; want ErrNotExist", err)
+	}
+}
@@ -0,0 +1,168 @@
+package clip
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a size in bytes that parses and prints human-readable units.
+// A *ByteSize implements [IOption], so it can be passed straight to
+// [Command.ArgOption] or [Command.Positional].
+//
+// Accepted units (case-insensitive, optionally separated by a space):
+//
+//	B                          bytes
+//	KB  MB  GB  TB  PB  EB     powers of 1000
+//	KiB MiB GiB TiB PiB EiB    powers of 1024
+//	K   M   G   T   P   E      powers of 1024, as in log rotation sizes
+//
+// The number may have a fractional part ("1.5G"); the result is truncated to
+// a whole number of bytes.  An integer may instead have a base prefix, as in
+// "0x100000" or "0o17K", and underscores, as for [strconv.ParseInt].  Such an
+// integer takes only the single-letter units K, M, G, T and P: hex digits are
+// read greedily, so "0x1B" is 27 bytes and "0x1E" is 30, and any other unit,
+// as in "0o1B" or "0x10KB", is rejected.  A leading zero alone does not mean
+// octal.  Values that do not fit in an int64 are rejected.
+type ByteSize int64
+
+// Common sizes.  To count the number of units in a ByteSize, divide:
+//
+//	n := size / clip.MiB
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+	EB ByteSize = 1000 * PB
+
+	KiB ByteSize = 1024 * Byte
+	MiB ByteSize = 1024 * KiB
+	GiB ByteSize = 1024 * MiB
+	TiB ByteSize = 1024 * GiB
+	PiB ByteSize = 1024 * TiB
+	EiB ByteSize = 1024 * PiB
+)
+
+var (
+	binarySizes  = [...]ByteSize{EiB, PiB, TiB, GiB, MiB, KiB}
+	decimalSizes = [...]ByteSize{EB, PB, TB, GB, MB, KB}
+	sizePrefixes = "EPTGMK"
+)
+
+// sizeUnit returns the multiplier for a unit suffix such as "MiB" or "k".
+func sizeUnit(u string) (ByteSize, bool) {
+	u = strings.ToUpper(u)
+	if u == "" || u == "B" {
+		return Byte, true
+	}
+	i := strings.IndexByte(sizePrefixes, u[0])
+	if i < 0 {
+		return 0, false
+	}
+	switch u[1:] {
+	case "", "I", "IB":
+		return binarySizes[i], true
+	case "B":
+		return decimalSizes[i], true
+	}
+	return 0, false
+}
+
+// sizeDigits returns the digits of the given base, or of a decimal number
+// with a fraction for base 10.
+func sizeDigits(base byte) string {
+	switch base {
+	case 'x', 'X':
+		return "0123456789abcdefABCDEF_"
+	case 'o', 'O':
+		return "01234567_"
+	case 'b', 'B':
+		return "01_"
+	}
+	return "0123456789."
+}
+
+// ParseByteSize parses a size such as "512MiB", "1.5G", "4096" or "0x1000K".
+func ParseByteSize(s string) (ByteSize, error) {
+	str := strings.TrimSpace(s)
+	var base byte
+	if len(str) > 2 && str[0] == '0' && strings.IndexByte("xXoObB", str[1]) >= 0 &&
+		strings.IndexByte(sizeDigits(str[1]), str[2]) >= 0 {
+		base = str[1]
+	}
+	digits := sizeDigits(base)
+	i := strings.IndexFunc(str, func(r rune) bool { return !strings.ContainsRune(digits, r) })
+	if base != 0 {
+		i = strings.IndexFunc(str[2:], func(r rune) bool { return !strings.ContainsRune(digits, r) })
+		if i >= 0 {
+			i += 2
+		}
+	}
+	if i < 0 {
+		i = len(str)
+	}
+	num, unit := str[:i], strings.TrimSpace(str[i:])
+	factor, ok := sizeUnit(unit)
+	if base != 0 && unit != "" && (len(unit) != 1 || strings.ContainsAny(unit, "bBeE")) {
+		ok = false
+	}
+	if num == "" || num == "." || strings.Count(num, ".") > 1 || !ok {
+		return 0, msgf("'%s' is not a valid size", s)
+	}
+	r := new(big.Rat)
+	if base != 0 {
+		n, err := strconv.ParseInt(num, 0, 64)
+		if err != nil {
+			return 0, msgf("'%s' is not a valid size", s)
+		}
+		r.SetInt64(n)
+	} else if _, ok := r.SetString(num); !ok {
+		return 0, msgf("'%s' is not a valid size", s)
+	}
+	r.Mul(r, new(big.Rat).SetInt64(int64(factor)))
+	n := new(big.Int).Quo(r.Num(), r.Denom())
+	if !n.IsInt64() {
+		return 0, msgf("size '%s' overflows %d bytes", s, int64(math.MaxInt64))
+	}
+	return ByteSize(n.Int64()), nil
+}
+
+// String formats b with the largest unit that represents it exactly, using at
+// most two decimals and preferring binary units: 1536 prints as "1.5KiB",
+// 1500000 as "1.5MB", and sizes with no short exact form as plain bytes.
+// For b >= 0 the result parses back to b; a negative size prints as plain
+// bytes with a minus sign, which [ParseByteSize] rejects.
+func (b ByteSize) String() string {
+	for _, units := range [...][6]ByteSize{binarySizes, decimalSizes} {
+		for i, u := range units {
+			if b < u {
+				continue
+			}
+			s := new(big.Rat).SetFrac64(int64(b), int64(u)).FloatString(2)
+			s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+			suffix := sizePrefixes[i:i+1] + "B"
+			if units == binarySizes {
+				suffix = sizePrefixes[i:i+1] + "iB"
+			}
+			if v, err := ParseByteSize(s + suffix); err == nil && v == b {
+				return s + suffix
+			}
+			break
+		}
+	}
+	return fmt.Sprintf("%dB", int64(b))
+}
+
+func (b *ByteSize) Parse(s string) error {
+	v, err := ParseByteSize(s)
+	if err == nil {
+		*b = v
+	}
+	return err
+}
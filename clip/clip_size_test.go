@@ -0,0 +1,96 @@
+package clip
+
+import "testing"
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ByteSize
+	}{
+		{"0", 0},
+		{"4096", 4096},
+		{"12B", 12},
+		{"10k", 10 * KiB},
+		{"2M", 2 * MiB},
+		{"1KB", 1000},
+		{"1kb", 1000},
+		{"1KiB", 1024},
+		{"512MiB", 512 * MiB},
+		{"512 MiB", 512 * MiB},
+		{"3MB", 3 * MB},
+		{"1.5G", GiB + GiB/2},
+		{"1.5GB", 1500 * MB},
+		{"2T", 2 * TiB},
+		{"0.5KiB", 512},
+		{"0x100000", MiB},
+		{"0x10K", 16 * KiB},
+		{"0x1B", 27},
+		{"0o1_7", 15},
+		{"0b101", 5},
+		{"0o1_7 k", 15 * KiB},
+		{"0x1E", 30},
+		{"010", 10},
+		{"010k", 10 * KiB},
+		{"0B", 0},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseByteSize(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseByteSizeRejects(t *testing.T) {
+	for _, s := range []string{"", "abc", "MiB", "1.2.3", "-1K", "10X", "1KiBB", "8EiB", "9223372036854775808", "0x", "0xg", "0o8", "0x1.5K",
+		"0o1B", "0b101 B", "0x10KB", "0x10 KiB", "0o1E", "-0x10"} {
+		if n, err := ParseByteSize(s); err == nil {
+			t.Errorf("ParseByteSize(%q) = %d; expected error", s, n)
+		}
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	tests := []struct {
+		in   ByteSize
+		want string
+	}{
+		{0, "0B"},
+		{100, "100B"},
+		{1024, "1KiB"},
+		{1536, "1.5KiB"},
+		{512 * MiB, "512MiB"},
+		{1000, "1KB"},
+		{1500 * KB, "1.5MB"},
+		{1000001, "1000001B"},
+		{EiB, "1EiB"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("ByteSize(%d).String() = %q; want %q", int64(tt.in), got, tt.want)
+		}
+		if back, err := ParseByteSize(tt.in.String()); err != nil || back != tt.in {
+			t.Errorf("%q does not round-trip: %d, %v", tt.in.String(), back, err)
+		}
+	}
+	// Negative sizes are printed but, like "-1K", never parsed.
+	for in, want := range map[ByteSize]string{-1: "-1B", -KiB: "-1024B"} {
+		if got := in.String(); got != want {
+			t.Errorf("ByteSize(%d).String() = %q; want %q", int64(in), got, want)
+		}
+	}
+}
+
+func TestArgOptionByteSize(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	limit := 64 * MiB
+	p.ArgOption(&limit, 0, "limit", "SIZE", "")
+	if _, err := p.Parse([]string{"prog", "--limit", "1.5GiB"}); err != nil {
+		t.Fatal(err)
+	}
+	if limit != GiB+GiB/2 {
+		t.Errorf("limit = %s; want 1.5GiB", limit)
+	}
+}
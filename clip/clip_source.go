@@ -0,0 +1,184 @@
+package clip
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// Env makes o fall back to the environment variable name when it is not
+// given on the command line.  The variable holds the option's value, not a
+// command-line token, so a flag accepts "true" or "false" (anything
+// strconv.ParseBool accepts) and an increment option takes a count.  As on
+// the command line, "true" for a [Option.ReverseFlag] means the flag was
+// given, which stores false.  An empty
+// variable counts as unset.  Help shows the variable next to the option.
+func (o *Option) Env(name string) *Option {
+	o.env = name
+	return o
+}
+
+// SetValue parses s into o's variable as if it had been given on the command
+// line, and records it as the value to use whenever o is not set by the
+// command line, the environment or the config (see [Parser.SetConfig]).  It
+// returns the error from parsing s.
+func (o *Option) SetValue(s string) error {
+	if err := o.parse(s); err != nil {
+		return err
+	}
+	o.prog = &s
+	return nil
+}
+
+// Source reports where o's value came from in the last Parse.
+func (o *Option) Source() Source { return o.src }
+
+// SetConfig supplies option values read from a configuration file, in
+// whatever format the program uses.  Keys are long option names, prefixed
+// with the path of sub-command names for options that do not belong to the
+// root, e.g. "verbose" and "serve.port"; positionals use their name.  Values
+// are parsed like those from [Option.Env].  A config value is used only for
+// an option that the command line and the environment leave unset.
+func (p *Parser) SetConfig(values map[string]string) *Parser {
+	p.config = values
+	return p
+}
+
+// configKey returns the key under which SetConfig looks up o, an option or
+// positional of c.
+func configKey(c *Command, o *Option) string {
+	key := o.longName
+	for ; c.parent != nil; c = c.parent {
+		key = c.Name + "." + key
+	}
+	return key
+}
+
+// applySources gives each option on the matched path, and each positional of
+// c, that the command line left unset its value from the environment, the
+// config or SetValue, whichever comes first.
+func applySources(r *Result, c *Command) error {
+	for pc := c; pc != nil; pc = pc.parent {
+		opts := pc.opts
+		if pc == c {
+			opts = slices.Concat(opts, c.positionals)
+		}
+		for _, o := range opts {
+			if o.v == nil || r.IsSet(o) {
+				continue
+			}
+			if v := os.Getenv(o.env); o.env != "" && v != "" {
+				if err := o.parseSetting(v); err != nil {
+					return errf("environment variable %s: %v", o.env, err)
+				}
+				r.mark(o, SourceEnv)
+			} else if v, ok := r.p.config[configKey(pc, o)]; ok && o.longName != "" {
+				if err := o.parseSetting(v); err != nil {
+					return errf("config '%s': %v", configKey(pc, o), err)
+				}
+				r.mark(o, SourceConfig)
+			} else if o.prog != nil {
+				if err := o.parse(*o.prog); err != nil {
+					return err
+				}
+				r.mark(o, SourceProgrammatic)
+			}
+		}
+	}
+	return nil
+}
+
+// parseSetting parses s, a value from the environment or the config, into o.
+// A reversed flag stores the opposite of s, as setNoArgOption does.
+func (o *Option) parseSetting(s string) error {
+	if err := o.parse(s); err != nil {
+		return err
+	}
+	if b, ok := o.v.(*clipBool); ok && o.reverseFlag {
+		*b = !*b
+	}
+	return nil
+}
+
+// setPrintConfig handles the built-in --print-config[=text|json] option.
+func (r *Result) setPrintConfig(format string, hasVal bool) error {
+	if !hasVal {
+		format = "text"
+	}
+	if format != "text" && format != "json" {
+		return errf("option 'print-config' accepts text or json, not '%s'", format)
+	}
+	r.printConfig = format
+	return nil
+}
+
+// configEntry is one line of --print-config output.
+type configEntry struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Origin string `json:"origin,omitempty"` // env variable or config key
+}
+
+// printConfig writes the effective value and source of every option and
+// positional of the matched command, including inherited ones, in the format
+// requested with --print-config.
+func (p *Parser) printConfig(r *Result) {
+	c := r.Command()
+	var entries []configEntry
+	for _, o := range append(c.lookupOpts(), c.positionals...) {
+		if o.v == nil {
+			continue
+		}
+		e := configEntry{Name: o.longName, Value: o.v.String(), Source: r.Source(o).String()}
+		if e.Name == "" {
+			e.Name = "-" + string(o.shortName)
+		}
+		if o.secret && e.Value != "" {
+			e.Value = "****"
+		}
+		switch r.Source(o) {
+		case SourceEnv:
+			e.Origin = o.env
+		case SourceConfig:
+			e.Origin = configKey(o.owner(c), o)
+		}
+		entries = append(entries, e)
+	}
+
+	w := p.output()
+	if r.printConfig == "json" {
+		path := make([]string, 0, len(r.path))
+		for _, pc := range r.path[1:] {
+			path = append(path, pc.Name)
+		}
+		data, _ := json.MarshalIndent(struct {
+			Command []string      `json:"command"`
+			Options []configEntry `json:"options"`
+		}{path, entries}, "", "  ")
+		fmt.Fprintf(w, "%s\n", data)
+		return
+	}
+	var nw, vw int
+	for _, e := range entries {
+		nw, vw = max(nw, len(e.Name)), max(vw, len(e.Value))
+	}
+	for _, e := range entries {
+		src := p.tr(e.Source)
+		if e.Origin != "" {
+			src += " " + e.Origin
+		}
+		fmt.Fprintf(w, "%-*s = %-*s  (%s)\n", nw, e.Name, vw, e.Value, src)
+	}
+}
+
+// owner returns the command on c's path that registered o.
+func (o *Option) owner(c *Command) *Command {
+	for pc := c; pc != nil; pc = pc.parent {
+		if slices.Contains(pc.opts, o) || slices.Contains(pc.positionals, o) {
+			return pc
+		}
+	}
+	return nil
+}
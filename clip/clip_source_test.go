@@ -0,0 +1,179 @@
+package clip
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newSourceParser() (p *Parser, port *int, token *string, verbose *bool) {
+	p = New()
+	port, token, verbose = new(int), new(string), new(bool)
+	*port = 80
+	p.FlagOption(verbose, 'v', "verbose", "").Env("APP_VERBOSE").Persistent()
+	serve := p.SubCommand("serve", "", "")
+	serve.ArgOption(port, 'p', "port", "PORT", "").Env("APP_PORT")
+	serve.ArgOption(token, 't', "token", "TOKEN", "").Secret()
+	return p, port, token, verbose
+}
+
+func TestSourcePrecedence(t *testing.T) {
+	p, port, _, verbose := newSourceParser()
+	defer p.Close()
+	p.SetConfig(map[string]string{"serve.port": "8000", "verbose": "true"})
+	portOpt := p.subcmds[0].opts[0]
+
+	r, err := p.ParseResult([]string{"prog", "serve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *port != 8000 || r.Source(portOpt) != SourceConfig || !*verbose {
+		t.Errorf("config: port = %d (%v), verbose = %v", *port, r.Source(portOpt), *verbose)
+	}
+
+	t.Setenv("APP_PORT", "8081")
+	t.Setenv("APP_VERBOSE", "false")
+	if _, err = p.Parse([]string{"prog", "serve"}); err != nil {
+		t.Fatal(err)
+	}
+	if *port != 8081 || portOpt.Source() != SourceEnv || *verbose {
+		t.Errorf("env: port = %d (%v), verbose = %v", *port, portOpt.Source(), *verbose)
+	}
+
+	if _, err = p.Parse([]string{"prog", "serve", "-p", "9"}); err != nil {
+		t.Fatal(err)
+	}
+	if *port != 9 || portOpt.Source() != SourceCommandLine {
+		t.Errorf("cli: port = %d (%v)", *port, portOpt.Source())
+	}
+
+	t.Setenv("APP_PORT", "x")
+	if _, err = p.Parse([]string{"prog", "serve"}); err == nil || !strings.Contains(err.Error(), "APP_PORT") {
+		t.Errorf("err = %v; want error naming APP_PORT", err)
+	}
+}
+
+func TestSourceProgrammatic(t *testing.T) {
+	p, port, _, _ := newSourceParser()
+	defer p.Close()
+	portOpt := p.subcmds[0].opts[0]
+
+	if err := portOpt.SetValue("x"); err == nil {
+		t.Error("SetValue(\"x\") on int option succeeded")
+	}
+	if err := portOpt.SetValue("3000"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Parse([]string{"prog", "serve"}); err != nil {
+		t.Fatal(err)
+	}
+	if *port != 3000 || portOpt.Source() != SourceProgrammatic {
+		t.Errorf("port = %d (%v); want 3000 (programmatic)", *port, portOpt.Source())
+	}
+	p.SetConfig(map[string]string{"serve.port": "4000"})
+	if _, err := p.Parse([]string{"prog", "serve"}); err != nil {
+		t.Fatal(err)
+	}
+	if *port != 4000 {
+		t.Errorf("port = %d; want config to beat SetValue", *port)
+	}
+}
+
+func TestSourceReverseFlag(t *testing.T) {
+	p := New()
+	defer p.Close()
+	cache := true
+	p.FlagOption(&cache, 0, "no-cache", "").ReverseFlag().Env("APP_NO_CACHE")
+
+	t.Setenv("APP_NO_CACHE", "true")
+	if _, err := p.Parse([]string{"prog"}); err != nil {
+		t.Fatal(err)
+	}
+	if cache {
+		t.Error("APP_NO_CACHE=true left cache on; want it to act like --no-cache")
+	}
+	t.Setenv("APP_NO_CACHE", "")
+	p.SetConfig(map[string]string{"no-cache": "true"})
+	if _, err := p.Parse([]string{"prog"}); err != nil {
+		t.Fatal(err)
+	}
+	if cache {
+		t.Error("config no-cache = true left cache on")
+	}
+}
+
+func TestSourceSatisfiesMustSet(t *testing.T) {
+	p := New()
+	defer p.Close()
+	var name string
+	p.ArgOption(&name, 'n', "name", "NAME", "").MustSet().Env("APP_NAME")
+
+	t.Setenv("APP_NAME", "bob")
+	if _, err := p.Parse([]string{"prog"}); err != nil {
+		t.Fatal(err)
+	}
+	if name != "bob" {
+		t.Errorf("name = %q; want bob", name)
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	p, _, _, _ := newSourceParser()
+	defer p.Close()
+	var out bytes.Buffer
+	p.SetOutput(&out)
+	t.Setenv("APP_PORT", "8081")
+	p.SetConfig(map[string]string{"serve.token": "s3cret"})
+
+	_, err := p.Parse([]string{"prog", "serve", "--print-config", "-v"})
+	if !errors.Is(err, ErrHelp) {
+		t.Fatalf("err = %v; want ErrHelp", err)
+	}
+	want := "port    = 8081  (env APP_PORT)\n" +
+		"token   = ****  (config serve.token)\n" +
+		"verbose = true  (command line)\n"
+	if out.String() != want {
+		t.Errorf("text output:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	if _, err = p.Parse([]string{"prog", "serve", "--print-config=json"}); !errors.Is(err, ErrHelp) {
+		t.Fatalf("err = %v; want ErrHelp", err)
+	}
+	var got struct {
+		Command []string
+		Options []struct{ Name, Value, Source, Origin string }
+	}
+	if err = json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("%v\n%s", err, out.String())
+	}
+	if len(got.Command) != 1 || got.Command[0] != "serve" || len(got.Options) != 3 {
+		t.Fatalf("json output:\n%s", out.String())
+	}
+	if o := got.Options[1]; o.Name != "token" || o.Value != "****" || o.Source != "config" {
+		t.Errorf("token entry = %+v", o)
+	}
+
+	if _, err = p.Parse([]string{"prog", "--print-config=yaml"}); err == nil || errors.Is(err, ErrHelp) {
+		t.Errorf("err = %v; want bad format error", err)
+	}
+}
+
+func TestHelpShowsEnvAndHidesSecret(t *testing.T) {
+	p := New()
+	defer p.Close()
+	var out bytes.Buffer
+	p.SetOutput(&out)
+	token := "dflt-token"
+	p.ArgOption(&token, 't', "token", "TOKEN", "API token").Env("APP_TOKEN").Secret()
+
+	p.HelpCommand(&p.Command, false)
+	if !strings.Contains(out.String(), "(env: APP_TOKEN)") {
+		t.Errorf("help lacks env variable:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "dflt-token") || strings.Contains(out.String(), "print-config") {
+		t.Errorf("help shows secret or hidden option:\n%s", out.String())
+	}
+}
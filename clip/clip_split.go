@@ -0,0 +1,127 @@
+package clip
+
+import (
+	"os"
+	"strings"
+)
+
+// SplitCommandLine splits s into tokens the way a POSIX shell splits a
+// simple command, without expansions or comments:
+//
+//   - blanks (space, tab, newline) separate tokens;
+//   - 'single quotes' preserve everything up to the next single quote;
+//   - "double quotes" preserve everything except \" \\ \$ \` and
+//     backslash-newline, which are unescaped;
+//   - outside quotes a backslash escapes the next character, and
+//     backslash-newline joins lines.
+//
+// Quotes may be adjacent to other text ("a"'b'c is one token, abc), and ""
+// yields an empty token.  An unterminated quote or trailing backslash is an
+// error.  A # is ordinary text, so "deploy #42" is two tokens.
+func SplitCommandLine(s string) ([]string, error) {
+	return splitShell(s, false)
+}
+
+// splitShell implements SplitCommandLine.  With comments set, as for response
+// files, a # at the start of a token also starts a comment that runs to the
+// end of the line.
+func splitShell(s string, comments bool) ([]string, error) {
+	var toks []string
+	var tok strings.Builder
+	inTok := false // tok holds a token, possibly empty ("")
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if inTok {
+				toks = append(toks, tok.String())
+				tok.Reset()
+				inTok = false
+			}
+		case ch == '#' && !inTok && comments:
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case ch == '\\':
+			if i+1 >= len(s) {
+				return nil, msgf("trailing backslash")
+			}
+			i++
+			if s[i] != '\n' {
+				tok.WriteByte(s[i])
+				inTok = true
+			}
+		case ch == '\'':
+			j := strings.IndexByte(s[i+1:], '\'')
+			if j < 0 {
+				return nil, msgf("unterminated single quote at offset %d", i)
+			}
+			tok.WriteString(s[i+1 : i+1+j])
+			i += j + 1
+			inTok = true
+		case ch == '"':
+			start := i
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				tok.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, msgf("unterminated double quote at offset %d", start)
+			}
+			inTok = true
+		default:
+			tok.WriteByte(ch)
+			inTok = true
+		}
+	}
+	if inTok {
+		toks = append(toks, tok.String())
+	}
+	return toks, nil
+}
+
+// maxResponseDepth limits how deeply response files may include each other,
+// which also stops a file that includes itself.
+const maxResponseDepth = 16
+
+// expandResponseFiles replaces every "@path" token in args with the tokens
+// read from path (see SplitCommandLine; # comments are allowed), recursively.  Paths are relative to the
+// working directory, as with gcc.  Expansion stops at a bare "--", whether it
+// comes from args or from a file; a lone "@" is kept as is.
+func expandResponseFiles(args []string) ([]string, error) {
+	var out []string
+	done := false
+	var expand func(args []string, depth int) error
+	expand = func(args []string, depth int) error {
+		for _, a := range args {
+			if done || len(a) < 2 || a[0] != '@' {
+				out = append(out, a)
+				done = done || a == "--"
+				continue
+			}
+			path := a[1:]
+			if depth >= maxResponseDepth {
+				return errf("response file '%s' nested more than %d levels deep", path, maxResponseDepth)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return errf("response file: %v", err)
+			}
+			toks, err := splitShell(string(data), true)
+			if err != nil {
+				return errf("response file '%s': %v", path, err)
+			}
+			if err = expand(toks, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err := expand(args, 0)
+	return out, err
+}
@@ -0,0 +1,194 @@
+package clip
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"  a  b\tc\n", []string{"a", "b", "c"}},
+		{`'a b' "c d"`, []string{"a b", "c d"}},
+		{`"a"'b'c`, []string{"abc"}},
+		{`"" ''`, []string{"", ""}},
+		{`a\ b`, []string{"a b"}},
+		{`"x \"y\" \\ \$HOME \n"`, []string{`x "y" \ $HOME \n`}},
+		{`'it''s'`, []string{"its"}},
+		{`'no \escapes'`, []string{`no \escapes`}},
+		{"a \\\nb", []string{"a", "b"}},
+		{"deploy #42", []string{"deploy", "#42"}},
+		{"a\r\nb", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		got, err := SplitCommandLine(tt.in)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("SplitCommandLine(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestSplitShellComments(t *testing.T) {
+	got, err := splitShell("# comment\n-v # trailing\n--out=x#y '#q'", true)
+	if want := []string{"-v", "--out=x#y", "#q"}; err != nil || !slices.Equal(got, want) {
+		t.Errorf("splitShell = %q, %v; want %q", got, err, want)
+	}
+}
+
+func TestSplitCommandLineErrors(t *testing.T) {
+	for _, s := range []string{`'abc`, `"abc`, `"a\"`, `abc\`} {
+		if got, err := SplitCommandLine(s); err == nil {
+			t.Errorf("SplitCommandLine(%q) = %q; expected error", s, got)
+		}
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	inner := writeFile(t, dir, "inner.rsp", "-v\n'file two'\n")
+	outer := writeFile(t, dir, "outer.rsp", "# generated\n--out \"a b\" @"+inner+"\n")
+
+	p := New().ResponseFiles(true)
+	defer p.Close()
+
+	var out string
+	var v bool
+	var files []string
+	p.ArgOption(&out, 'o', "out", "F", "")
+	p.FlagOption(&v, 'v', "verbose", "")
+	p.Positional(&files, "FILE", "").Arity(ArityAny)
+	cmd, err := p.Parse([]string{"prog", "first", "@" + outer, "--", "@literal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "a b" || !v || !slices.Equal(files, []string{"first", "file two"}) {
+		t.Errorf("out=%q v=%v files=%q", out, v, files)
+	}
+	if !slices.Equal(cmd.Arguments, []string{"@literal"}) {
+		t.Errorf("Arguments = %q; want [@literal]", cmd.Arguments)
+	}
+}
+
+func TestResponseFilesEmptyToken(t *testing.T) {
+	dir := t.TempDir()
+	rsp := writeFile(t, dir, "args.rsp", "a '' b\n")
+
+	p := New().ResponseFiles(true)
+	defer p.Close()
+	var files []string
+	p.Positional(&files, "FILE", "").Arity(ArityAny)
+	if _, err := p.Parse([]string{"prog", "@" + rsp}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(files, []string{"a", "", "b"}) {
+		t.Errorf("files = %q; want [a  b]", files)
+	}
+
+	q := New().ResponseFiles(true)
+	defer q.Close()
+	q.SubCommand("serve", "", "")
+	if _, err := q.Parse([]string{"prog", "@" + writeFile(t, dir, "empty.rsp", `""`)}); err == nil {
+		t.Error("empty token selected a sub-command")
+	}
+}
+
+func TestResponseFilesOffByDefault(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var s string
+	p.Positional(&s, "ARG", "")
+	if _, err := p.Parse([]string{"prog", "@nonexistent"}); err != nil {
+		t.Fatal(err)
+	}
+	if s != "@nonexistent" {
+		t.Errorf("s = %q; want @nonexistent", s)
+	}
+}
+
+func TestResponseFilesRecursionLimit(t *testing.T) {
+	dir := t.TempDir()
+	self := filepath.Join(dir, "self.rsp")
+	writeFile(t, dir, "self.rsp", "@"+self)
+
+	p := New().ResponseFiles(true)
+	defer p.Close()
+	if _, err := p.Parse([]string{"prog", "@" + self}); err == nil {
+		t.Error("expected error for self-including response file")
+	}
+}
+
+func TestResponseFilesErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := writeFile(t, dir, "bad.rsp", "'unterminated")
+
+	for _, arg := range []string{"@" + bad, "@" + filepath.Join(dir, "missing.rsp")} {
+		p := New().ResponseFiles(true)
+		if _, err := p.Parse([]string{"prog", arg}); err == nil {
+			t.Errorf("%s: expected error", arg)
+		}
+		p.Close()
+	}
+}
+
+func TestParseString(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var port int
+	var msg string
+	serve := p.SubCommand("serve", "", "")
+	serve.ArgOption(&port, 'p', "port", "PORT", "")
+	serve.ArgOption(&msg, 'm', "motd", "TEXT", "")
+
+	cmd, err := p.ParseString(`serve -p 8080 --motd "hello, world" extra`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Name != "serve" || port != 8080 || msg != "hello, world" {
+		t.Errorf("cmd=%s port=%d msg=%q", cmd.Name, port, msg)
+	}
+	if !slices.Equal(cmd.Arguments, []string{"extra"}) {
+		t.Errorf("Arguments = %q; want [extra]", cmd.Arguments)
+	}
+}
+
+func TestParseStringUnterminated(t *testing.T) {
+	p := New()
+	defer p.Close()
+	if _, err := p.ParseString(`serve "oops`); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
+
+func TestParseStringEmptyToken(t *testing.T) {
+	p := New()
+	defer p.Close()
+	set := p.SubCommand("set", "", "")
+	var key, val string
+	set.Positional(&key, "key", "")
+	set.Positional(&val, "value", "")
+	val = "unchanged"
+
+	cmd, err := p.ParseString(`set name ''`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Name != "set" || key != "name" || val != "" {
+		t.Errorf("cmd=%s key=%q value=%q; want set, name and an empty value", cmd.Name, key, val)
+	}
+}
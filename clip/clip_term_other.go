@@ -0,0 +1,20 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package clip
+
+import (
+	"errors"
+	"os"
+)
+
+// isTerminal reports whether f is a character device, which is as close to
+// "is a terminal" as the standard library gets on this platform.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// disableEcho is not supported here, so hidden answers are echoed.
+func disableEcho(f *os.File) (restore func(), err error) {
+	return nil, errors.New("cannot turn off terminal echo on this platform")
+}
@@ -1,6 +1,7 @@
 package clip
 
 import (
+	"bytes"
 	"errors"
 	"os"
 	"runtime"
@@ -777,3 +778,611 @@ func TestRunNotRunnableError(t *testing.T) {
 		t.Errorf("got %v; want ErrNotRunnable", err)
 	}
 }
+
+// ---- Positional arity -------------------------------------------------------
+
+func TestPositionalVariadicBeforeFixed(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var srcs []string
+	var dst string
+	p.Positional(&srcs, "SRC", "").Arity(ArityOneOrMore)
+	p.Positional(&dst, "DST", "")
+	cmd, err := p.Parse([]string{"prog", "a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(srcs) != 2 || srcs[0] != "a" || srcs[1] != "b" || dst != "c" {
+		t.Errorf("srcs=%v dst=%q; want [a b] / c", srcs, dst)
+	}
+	if len(cmd.Arguments) != 0 {
+		t.Errorf("Arguments = %v; want none", cmd.Arguments)
+	}
+}
+
+func TestPositionalOptionalBeforeVariadic(t *testing.T) {
+	var srcs []string
+	var dst, mode string
+	tests := []struct {
+		args      string
+		srcs      string
+		dst, mode string
+	}{
+		{"a", "a", "", ""},
+		{"a b", "a", "b", ""},
+		{"a b c", "a", "b", "c"},
+		{"a b c d", "a,b", "c", "d"},
+	}
+	for _, tt := range tests {
+		p := New()
+		p.Positional(&srcs, "SRC", "").Arity(ArityOneOrMore)
+		p.Positional(&dst, "DST", "").Arity(ArityOptional)
+		p.Positional(&mode, "MODE", "").Arity(ArityOptional)
+		srcs, dst, mode = nil, "", ""
+		if _, err := p.Parse(append([]string{"prog"}, strings.Fields(tt.args)...)); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(srcs, ",") != tt.srcs || dst != tt.dst || mode != tt.mode {
+			t.Errorf("%q: srcs=%v dst=%q mode=%q; want %s / %q / %q", tt.args, srcs, dst, mode, tt.srcs, tt.dst, tt.mode)
+		}
+		p.Close()
+	}
+}
+
+func TestPositionalOneOrMoreMissing(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var srcs []string
+	var dst string
+	p.Positional(&srcs, "SRC", "").Arity(ArityOneOrMore)
+	p.Positional(&dst, "DST", "")
+	if _, err := p.Parse([]string{"prog", "only"}); err == nil {
+		t.Error("expected error for missing SRC")
+	}
+}
+
+func TestPositionalAnyMayBeEmpty(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var files []string
+	p.Positional(&files, "FILE", "").Arity(ArityAny)
+	if _, err := p.Parse([]string{"prog"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("files = %v; want none", files)
+	}
+}
+
+func TestPositionalVariadicInterleavedWithOptions(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var files []string
+	var v bool
+	p.FlagOption(&v, 'v', "verbose", "")
+	p.Positional(&files, "FILE", "").Arity(ArityAny)
+	cmd, err := p.Parse([]string{"prog", "a", "-v", "b", "--", "-c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v || len(files) != 2 || files[1] != "b" {
+		t.Errorf("v=%v files=%v; want true [a b]", v, files)
+	}
+	if len(cmd.Arguments) != 1 || cmd.Arguments[0] != "-c" {
+		t.Errorf("Arguments = %v; want [-c]", cmd.Arguments)
+	}
+}
+
+func TestPositionalOptional(t *testing.T) {
+	for _, tt := range []struct {
+		args      []string
+		name, out string
+	}{
+		{[]string{"prog", "in"}, "", "in"},
+		{[]string{"prog", "a", "in"}, "a", "in"},
+	} {
+		p := New()
+		var name, out string
+		p.Positional(&name, "NAME", "").Arity(ArityOptional)
+		p.Positional(&out, "OUT", "")
+		if _, err := p.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		p.Close()
+		if name != tt.name || out != tt.out {
+			t.Errorf("%v: name=%q out=%q; want %q/%q", tt.args, name, out, tt.name, tt.out)
+		}
+	}
+}
+
+func TestPositionalVariadicReplacesDefault(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	files := []string{"default.txt"}
+	p.Positional(&files, "FILE", "").Arity(ArityAny)
+	if _, err := p.Parse([]string{"prog", "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0] != "x" {
+		t.Errorf("files = %v; want [x]", files)
+	}
+}
+
+func TestPositionalNameArity(t *testing.T) {
+	var s string
+	var l []string
+	c := &Command{}
+	tests := []struct {
+		o    *Option
+		want string
+	}{
+		{c.Positional(&s, "A", ""), "<A>"},
+		{c.Positional(&s, "B", "").Arity(ArityOptional), "[B]"},
+		{c.Positional(&l, "C", "").Arity(ArityOneOrMore), "<C>..."},
+		{(&Command{}).Positional(&l, "D", "").Arity(ArityAny), "[D...]"},
+	}
+	for _, tt := range tests {
+		if got := positionalName(tt.o); got != tt.want {
+			t.Errorf("positionalName = %q; want %q", got, tt.want)
+		}
+	}
+}
+
+func TestArityOnOptionPanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic")
+		}
+	}()
+	var s string
+	(&Command{}).ArgOption(&s, 'f', "file", "F", "").Arity(ArityAny)
+}
+
+// ---- Persistent options -----------------------------------------------------
+
+func TestPersistentOptionAfterSubCommand(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var v bool
+	var cfg string
+	p.FlagOption(&v, 'v', "verbose", "").Persistent()
+	p.ArgOption(&cfg, 'c', "config", "FILE", "").Persistent()
+	serve := p.SubCommand("serve", "", "")
+	serve.SubCommand("http", "", "")
+
+	cmd, err := p.Parse([]string{"prog", "serve", "http", "-v", "--config", "a.conf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Name != "http" || !v || cfg != "a.conf" {
+		t.Errorf("cmd=%s v=%v cfg=%q; want http true a.conf", cmd.Name, v, cfg)
+	}
+}
+
+func TestNonPersistentOptionNotInherited(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var v bool
+	p.FlagOption(&v, 'v', "verbose", "")
+	p.SubCommand("serve", "", "")
+	if _, err := p.Parse([]string{"prog", "serve", "-v"}); err == nil {
+		t.Error("expected error for parent-only option after sub-command")
+	}
+}
+
+func TestPersistentOptionShadowedBySubCommand(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var rootOut, subOut string
+	p.ArgOption(&rootOut, 'o', "out", "F", "").Persistent()
+	sub := p.SubCommand("build", "", "")
+	sub.ArgOption(&subOut, 'o', "out", "F", "")
+
+	if _, err := p.Parse([]string{"prog", "build", "-o", "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if subOut != "x" || rootOut != "" {
+		t.Errorf("subOut=%q rootOut=%q; want x and empty", subOut, rootOut)
+	}
+}
+
+// ---- Long option abbreviation -----------------------------------------------
+
+func TestLongOptionAbbrevStrictByDefault(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var v bool
+	p.FlagOption(&v, 0, "verbose", "")
+	if _, err := p.Parse([]string{"prog", "--verb"}); err == nil {
+		t.Error("expected error for abbreviation in strict mode")
+	}
+}
+
+func TestLongOptionAbbrevUnique(t *testing.T) {
+	p := New().SetStrict(false)
+	defer p.Close()
+
+	var v bool
+	var out string
+	p.FlagOption(&v, 0, "verbose", "")
+	p.ArgOption(&out, 0, "output", "F", "")
+	if _, err := p.Parse([]string{"prog", "--verb", "--out=x"}); err != nil {
+		t.Fatal(err)
+	}
+	if !v || out != "x" {
+		t.Errorf("v=%v out=%q; want true x", v, out)
+	}
+}
+
+func TestLongOptionAbbrevAmbiguous(t *testing.T) {
+	p := New().SetStrict(false)
+	defer p.Close()
+
+	var a, b bool
+	p.FlagOption(&a, 0, "verbose", "")
+	p.FlagOption(&b, 0, "verbatim", "")
+	_, err := p.Parse([]string{"prog", "--verb"})
+	if err == nil || !strings.Contains(err.Error(), "--verbose") || !strings.Contains(err.Error(), "--verbatim") {
+		t.Errorf("err = %v; want ambiguity error listing both candidates", err)
+	}
+}
+
+func TestLongOptionAbbrevExactWins(t *testing.T) {
+	p := New().SetStrict(false)
+	defer p.Close()
+
+	var a, b bool
+	p.FlagOption(&a, 0, "verb", "")
+	p.FlagOption(&b, 0, "verbose", "")
+	if _, err := p.Parse([]string{"prog", "--verb"}); err != nil {
+		t.Fatal(err)
+	}
+	if !a || b {
+		t.Errorf("a=%v b=%v; want exact match --verb only", a, b)
+	}
+}
+
+func TestLongOptionAbbrevHelp(t *testing.T) {
+	p := New().SetStrict(false)
+	defer p.Close()
+
+	if _, err := p.Parse([]string{"prog", "--he"}); !errors.Is(err, ErrHelp) {
+		t.Errorf("got %v; want ErrHelp", err)
+	}
+}
+
+// ---- Negated flags ----------------------------------------------------------
+
+func TestNegatedFlag(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	color := true
+	p.FlagOption(&color, 0, "color", "")
+	if _, err := p.Parse([]string{"prog", "--no-color"}); err != nil {
+		t.Fatal(err)
+	}
+	if color {
+		t.Error("--no-color should have set color to false")
+	}
+}
+
+func TestNegatedReverseFlag(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	cache := true
+	p.FlagOption(&cache, 0, "skip-cache", "").ReverseFlag()
+	if _, err := p.Parse([]string{"prog", "--no-skip-cache"}); err != nil {
+		t.Fatal(err)
+	}
+	if !cache {
+		t.Error("--no-skip-cache on a reverse flag should store true")
+	}
+}
+
+func TestNegatedFlagNotForArgOrNoPrefix(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var out string
+	var v bool
+	p.ArgOption(&out, 0, "out", "F", "")
+	p.FlagOption(&v, 0, "no-verbose", "")
+	for _, arg := range []string{"--no-out", "--no-no-verbose"} {
+		if _, err := p.Parse([]string{"prog", arg}); err == nil {
+			t.Errorf("%s: expected error", arg)
+		}
+	}
+}
+
+func TestNegatedFlagRejectsValue(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var color bool
+	p.FlagOption(&color, 0, "color", "")
+	if _, err := p.Parse([]string{"prog", "--no-color=1"}); err == nil {
+		t.Error("expected error for --no-color=1")
+	}
+}
+
+func TestNegatedFlagAbbrev(t *testing.T) {
+	p := New().SetStrict(false)
+	defer p.Close()
+
+	color := true
+	p.FlagOption(&color, 0, "color", "")
+	if _, err := p.Parse([]string{"prog", "--no-col"}); err != nil {
+		t.Fatal(err)
+	}
+	if color {
+		t.Error("--no-col should resolve to --no-color")
+	}
+}
+
+func TestPersistentOptionHelp(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var v bool
+	p.FlagOption(&v, 'v', "verbose", "Verbose output").Persistent()
+	sub := p.SubCommand("serve", "Start server", "")
+
+	var buf bytes.Buffer
+	p.SetOutput(&buf)
+	p.HelpCommand(sub, false)
+	out := buf.String()
+	if !strings.Contains(out, "Global Options:") || !strings.Contains(out, "-v,--[no-]verbose") {
+		t.Errorf("help lacks global --verbose:\n%s", out)
+	}
+}
+
+func TestNegatedFlagHelp(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var color, noCache bool
+	var out string
+	p.FlagOption(&color, 0, "color", "")
+	p.FlagOption(&noCache, 0, "no-cache", "")
+	p.ArgOption(&out, 'o', "out", "FILE", "")
+
+	var buf bytes.Buffer
+	p.SetOutput(&buf)
+	p.HelpCommand(nil, false)
+	help := buf.String()
+	for _, want := range []string{"--[no-]color", "  --no-cache", "-o,--out <FILE>"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help lacks %q:\n%s", want, help)
+		}
+	}
+}
+
+// ---- Optional option arguments ----------------------------------------------
+
+func TestImplicitLong(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want string
+		rest int
+	}{
+		{[]string{"prog", "--color"}, "always", 0},
+		{[]string{"prog", "--color=never"}, "never", 0},
+		{[]string{"prog", "--color", "never"}, "always", 1},
+		{[]string{"prog"}, "auto", 0},
+	} {
+		p := New()
+		color := "auto"
+		p.ArgOption(&color, 0, "color", "WHEN", "").Implicit("always")
+		cmd, err := p.Parse(tt.args)
+		p.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if color != tt.want || len(cmd.Arguments) != tt.rest {
+			t.Errorf("%v: color=%q Arguments=%v; want %q and %d argument(s)",
+				tt.args, color, cmd.Arguments, tt.want, tt.rest)
+		}
+	}
+}
+
+func TestImplicitShort(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want int
+		v    bool
+	}{
+		{[]string{"prog", "-O"}, 1, false},
+		{[]string{"prog", "-O3"}, 3, false},
+		{[]string{"prog", "-vO"}, 1, true},
+	} {
+		p := New()
+		var level int
+		var v bool
+		p.FlagOption(&v, 'v', "", "")
+		p.ArgOption(&level, 'O', "", "LEVEL", "").Implicit("1")
+		_, err := p.Parse(tt.args)
+		p.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if level != tt.want || v != tt.v {
+			t.Errorf("%v: level=%d v=%v; want %d %v", tt.args, level, v, tt.want, tt.v)
+		}
+	}
+}
+
+func TestImplicitOnFlagPanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic")
+		}
+	}()
+	var v bool
+	(&Command{}).FlagOption(&v, 'v', "verbose", "").Implicit("true")
+}
+
+func TestImplicitHelp(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var color string
+	var level int
+	p.ArgOption(&color, 0, "color", "WHEN", "").Implicit("always")
+	p.ArgOption(&level, 'O', "", "LEVEL", "").Implicit("1")
+
+	var buf bytes.Buffer
+	p.SetOutput(&buf)
+	p.HelpCommand(nil, false)
+	help := buf.String()
+	for _, want := range []string{"--color[=WHEN]", "-O[LEVEL]"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help lacks %q:\n%s", want, help)
+		}
+	}
+}
+
+// ---- Log output and clock ---------------------------------------------------
+
+func TestSetLogOutputAndClock(t *testing.T) {
+	var buf strings.Builder
+	now := time.Date(2024, 2, 3, 4, 5, 6, 0, time.Local)
+	p := New().SetLogOutput(&buf).SetClock(func() time.Time { return now })
+	sc := p.SubCommand("sub", "", "")
+	sc.SetRuns(func(c *Command) error { c.Logf("hello %d%%", 100); return nil }, nil, nil)
+
+	c, err := p.Parse([]string{"prog", "sub"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "2024/02/03 04:05:06 hello 100%\n"; buf.String() != want {
+		t.Errorf("log = %q; want %q", buf.String(), want)
+	}
+}
+
+// ---- Usage and examples -----------------------------------------------------
+
+func TestUsageSynopsis(t *testing.T) {
+	p := New()
+	defer p.Close()
+	p.Name = "prog"
+
+	var v bool
+	var addr, dst string
+	var srcs []string
+	p.FlagOption(&v, 'v', "verbose", "").Persistent()
+	serve := p.SubCommand("serve", "", "").AcceptArguments("ARGS")
+	serve.Positional(&addr, "ADDR", "")
+	cp := p.SubCommand("cp", "", "")
+	cp.Positional(&srcs, "SRC", "").Arity(ArityOneOrMore)
+	cp.Positional(&dst, "DST", "").Arity(ArityOptional)
+	bare := p.SubCommand("bare", "", "")
+	bare.SubCommand("x", "", "")
+	bare.SetRuns(func(*Command) error { return nil }, nil, nil)
+
+	tests := []struct {
+		c    *Command
+		want string
+	}{
+		{&p.Command, "prog [OPTIONS] <COMMAND>"},
+		{serve, "prog serve [OPTIONS] <ADDR> [ARGS...]"},
+		{cp, "prog cp [OPTIONS] <SRC>... [DST]"},
+		{bare, "prog bare [OPTIONS] [COMMAND]"},
+	}
+	for _, tt := range tests {
+		if got := p.synopsis(tt.c); got != tt.want {
+			t.Errorf("synopsis(%s) = %q; want %q", tt.c.Name, got, tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	p.SetOutput(&buf)
+	p.HelpCommand(serve, false)
+	help := buf.String()
+	if !strings.HasPrefix(help, "Usage: prog serve [OPTIONS] <ADDR> [ARGS...]\n\n") {
+		t.Errorf("help does not start with usage line:\n%s", help)
+	}
+}
+
+func TestHelpExamples(t *testing.T) {
+	p := New()
+	defer p.Close()
+	p.SubCommand("serve", "", "").
+		Example("prog serve :80", "Serve on port 80").
+		Example("prog serve -- -weird-addr", "")
+
+	var buf bytes.Buffer
+	p.SetOutput(&buf)
+	p.HelpCommand(p.subcmds[0], false)
+	help := buf.String()
+	want := "Examples:\n\n  # Serve on port 80\n  prog serve :80\n\n  prog serve -- -weird-addr\n\n"
+	if !strings.HasSuffix(help, want) {
+		t.Errorf("help does not end with examples:\n%s", help)
+	}
+}
+
+func TestHelpShowsDefaultNotParsedValue(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOutput(&buf)
+	defer p.Close()
+	n := 3
+	p.ArgOption(&n, 'n', "count", "N", "")
+
+	if _, err := p.Parse([]string{"prog", "-n", "5", "--help"}); !errors.Is(err, ErrHelp) {
+		t.Fatalf("err = %v; want ErrHelp", err)
+	}
+	if !strings.Contains(buf.String(), "(default: 3)") {
+		t.Errorf("help does not show the default 3:\n%s", buf.String())
+	}
+}
+
+// ---- Option groups ----------------------------------------------------------
+
+func TestOptionGroups(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var v, tls bool
+	var port int
+	var host, cert, logf string
+	p.FlagOption(&v, 'v', "verbose", "")
+	pOpt := p.ArgOption(&port, 'p', "port", "PORT", "")
+	p.FlagOption(&tls, 0, "tls", "").Group("TLS")
+	hOpt := p.ArgOption(&host, 0, "host", "HOST", "")
+	p.ArgOption(&cert, 0, "cert", "FILE", "").Group("TLS")
+	p.ArgOption(&logf, 0, "log", "FILE", "")
+	p.Group("Network", pOpt, hOpt)
+
+	var buf bytes.Buffer
+	p.SetOutput(&buf)
+	p.HelpCommand(nil, false)
+	help := buf.String()
+	idx := func(s string) int {
+		i := strings.Index(help, s)
+		if i < 0 {
+			t.Fatalf("help lacks %q:\n%s", s, help)
+		}
+		return i
+	}
+	order := []int{idx("Options:"), idx("--[no-]verbose"), idx("--log"),
+		idx("Network:"), idx("--port"), idx("--host"),
+		idx("TLS:"), idx("--[no-]tls"), idx("--cert")}
+	for i := 1; i < len(order); i++ {
+		if order[i] < order[i-1] {
+			t.Fatalf("help sections out of order:\n%s", help)
+		}
+	}
+}
@@ -2,9 +2,15 @@ package clip
 
 import (
     "fmt"
+    "math/big"
+    "net"
+    "net/netip"
+    "net/url"
+    "os"
+    "regexp"
     "strconv"
+    "strings"
     "time"
-    "net"
 )
 
 type (
@@ -43,8 +49,9 @@ func (i *clipUint32) String() string { return fmt.Sprintf("%d", *i) }
 func (i *clipUint64) String() string { return fmt.Sprintf("%d", *i) }
 
 func (i *clipInt) Parse(s string) (err error) {
-    if s, err := strconv.ParseInt(s, 0, 0); err == nil {
-        *i = clipInt(s)
+    v, err := strconv.ParseInt(s, 0, 0)
+    if err == nil {
+        *i = clipInt(v)
     }
     return
 }
@@ -175,7 +182,288 @@ func (i *clipIP) Parse(s string) (err error) {
     if v != nil {
         *i = clipIP(v)
     } else {
-        err = fmt.Errorf("'%s' is not valid IP address", s)
+        err = msgf("'%s' is not valid IP address", s)
+    }
+    return
+}
+
+type (
+    clipTime     time.Time
+    clipURL      url.URL
+    clipIPNet    net.IPNet
+    clipPrefix   netip.Prefix
+    clipAddrPort netip.AddrPort
+    clipMAC      net.HardwareAddr
+    clipFileMode os.FileMode
+    clipBigInt   big.Int
+    clipBigFloat big.Float
+
+    // Wrappers for variables that are themselves pointers (*url.URL etc.);
+    // the pointee is allocated on first Parse.
+    clipURLRef      struct{ p **url.URL }
+    clipRegexp      struct{ p **regexp.Regexp }
+    clipBigIntRef   struct{ p **big.Int }
+    clipBigFloatRef struct{ p **big.Float }
+
+    clipTimeLayout struct {
+        p       *time.Time
+        layouts []string
+    }
+)
+
+func (t *clipTime) String() string {
+    if time.Time(*t).IsZero() {
+        return ""
+    }
+    return time.Time(*t).Format(time.RFC3339Nano)
+}
+
+func (t *clipTime) Parse(s string) (err error) {
+    v, err := time.Parse(time.RFC3339, s)
+    if err == nil {
+        *t = clipTime(v)
+    }
+    return
+}
+
+// TimeLayout returns an [IOption] that parses a time.Time into p using the
+// given layouts, tried in order.  The first layout is used to print the value.
+// With no layouts, time.RFC3339 is used, as for a plain *time.Time.
+func TimeLayout(p *time.Time, layouts ...string) IOption {
+    if len(layouts) == 0 {
+        layouts = []string{time.RFC3339}
+    }
+    return &clipTimeLayout{p: p, layouts: layouts}
+}
+
+func (t *clipTimeLayout) String() string {
+    if t.p.IsZero() {
+        return ""
+    }
+    return t.p.Format(t.layouts[0])
+}
+
+func (t *clipTimeLayout) Parse(s string) (err error) {
+    for _, l := range t.layouts {
+        var v time.Time
+        if v, err = time.Parse(l, s); err == nil {
+            *t.p = v
+            return
+        }
+    }
+    if len(t.layouts) > 1 {
+        err = msgf("'%s' does not match any of the layouts %q", s, t.layouts)
+    }
+    return
+}
+
+func (u *clipURL) String() string { return (*url.URL)(u).String() }
+
+func (u *clipURL) Parse(s string) (err error) {
+    v, err := url.Parse(s)
+    if err == nil {
+        *u = clipURL(*v)
+    }
+    return
+}
+
+func (u *clipURLRef) String() string {
+    if *u.p == nil {
+        return ""
+    }
+    return (*u.p).String()
+}
+
+func (u *clipURLRef) Parse(s string) (err error) {
+    v, err := url.Parse(s)
+    if err == nil {
+        *u.p = v
+    }
+    return
+}
+
+func (n *clipIPNet) String() string {
+    if n.IP == nil {
+        return ""
+    }
+    return (*net.IPNet)(n).String()
+}
+
+func (n *clipIPNet) Parse(s string) (err error) {
+    _, v, err := net.ParseCIDR(s)
+    if err == nil {
+        *n = clipIPNet(*v)
+    }
+    return
+}
+
+func (p *clipPrefix) String() string {
+    if !netip.Prefix(*p).IsValid() {
+        return ""
+    }
+    return netip.Prefix(*p).String()
+}
+
+func (p *clipPrefix) Parse(s string) (err error) {
+    v, err := netip.ParsePrefix(s)
+    if err == nil {
+        *p = clipPrefix(v)
+    }
+    return
+}
+
+func (a *clipAddrPort) String() string {
+    if !netip.AddrPort(*a).IsValid() {
+        return ""
+    }
+    return netip.AddrPort(*a).String()
+}
+
+func (a *clipAddrPort) Parse(s string) (err error) {
+    v, err := netip.ParseAddrPort(s)
+    if err == nil {
+        *a = clipAddrPort(v)
     }
     return
 }
+
+func (m *clipMAC) String() string { return net.HardwareAddr(*m).String() }
+
+func (m *clipMAC) Parse(s string) (err error) {
+    v, err := net.ParseMAC(s)
+    if err == nil {
+        *m = clipMAC(v)
+    }
+    return
+}
+
+func (r *clipRegexp) String() string {
+    if *r.p == nil {
+        return ""
+    }
+    return (*r.p).String()
+}
+
+func (r *clipRegexp) Parse(s string) (err error) {
+    v, err := regexp.Compile(s)
+    if err == nil {
+        *r.p = v
+    }
+    return
+}
+
+// FileMode values are read and printed in octal, e.g. 0644 or 0o750.
+func (m *clipFileMode) String() string {
+    if *m == 0 {
+        return "0"
+    }
+    return "0" + strconv.FormatUint(uint64(*m), 8)
+}
+
+func (m *clipFileMode) Parse(s string) (err error) {
+    ss := s
+    if strings.HasPrefix(ss, "0o") || strings.HasPrefix(ss, "0O") {
+        ss = ss[2:]
+    }
+    v, err := strconv.ParseUint(ss, 8, 32)
+    if err == nil {
+        *m = clipFileMode(v)
+    } else {
+        err = msgf("'%s' is not a valid octal file mode", s)
+    }
+    return
+}
+
+func (i *clipBigInt) String() string { return (*big.Int)(i).String() }
+
+func (i *clipBigInt) Parse(s string) (err error) {
+    // SetString may leave its receiver modified when it fails.
+    if v, ok := new(big.Int).SetString(s, 0); ok {
+        (*big.Int)(i).Set(v)
+    } else {
+        err = msgf("'%s' is not a valid integer", s)
+    }
+    return
+}
+
+func (i *clipBigIntRef) String() string {
+    if *i.p == nil {
+        return ""
+    }
+    return (*i.p).String()
+}
+
+func (i *clipBigIntRef) Parse(s string) (err error) {
+    v := new(big.Int)
+    if err = (*clipBigInt)(v).Parse(s); err == nil {
+        *i.p = v
+    }
+    return
+}
+
+func (f *clipBigFloat) String() string { return (*big.Float)(f).Text('g', -1) }
+
+func (f *clipBigFloat) Parse(s string) (err error) {
+    // Parse at the variable's precision and mode, without touching it on
+    // failure.
+    z := (*big.Float)(f)
+    if v, ok := new(big.Float).SetPrec(z.Prec()).SetMode(z.Mode()).SetString(s); ok {
+        z.Set(v)
+    } else {
+        err = msgf("'%s' is not a valid number", s)
+    }
+    return
+}
+
+func (f *clipBigFloatRef) String() string {
+    if *f.p == nil {
+        return ""
+    }
+    return (*f.p).Text('g', -1)
+}
+
+func (f *clipBigFloatRef) Parse(s string) (err error) {
+    v := new(big.Float)
+    if err = (*clipBigFloat)(v).Parse(s); err == nil {
+        *f.p = v
+    }
+    return
+}
+
+// snapshot implementations for the types above whose state lives behind a
+// second pointer, or that SetString would modify in place.
+
+func (t *clipTimeLayout) snapshot() func() {
+    x := *t.p
+    return func() { *t.p = x }
+}
+
+func (u *clipURLRef) snapshot() func() {
+    x := *u.p
+    return func() { *u.p = x }
+}
+
+func (r *clipRegexp) snapshot() func() {
+    x := *r.p
+    return func() { *r.p = x }
+}
+
+func (i *clipBigInt) snapshot() func() {
+    x := new(big.Int).Set((*big.Int)(i))
+    return func() { (*big.Int)(i).Set(x) }
+}
+
+func (i *clipBigIntRef) snapshot() func() {
+    x := *i.p
+    return func() { *i.p = x }
+}
+
+func (f *clipBigFloat) snapshot() func() {
+    x := new(big.Float).Copy((*big.Float)(f))
+    return func() { (*big.Float)(f).Copy(x) }
+}
+
+func (f *clipBigFloatRef) snapshot() func() {
+    x := *f.p
+    return func() { *f.p = x }
+}
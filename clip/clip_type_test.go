@@ -0,0 +1,154 @@
+package clip
+
+import (
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// Every built-in type must print a value that parses back to itself, so the
+// "(default: …)" text in help can be pasted onto the command line.
+func TestBuiltinTypesRoundTrip(t *testing.T) {
+	var (
+		tm  time.Time
+		u   url.URL
+		up  *url.URL
+		ipn net.IPNet
+		pfx netip.Prefix
+		ap  netip.AddrPort
+		mac net.HardwareAddr
+		re  *regexp.Regexp
+		fm  os.FileMode
+		bi  big.Int
+		bip *big.Int
+		bf  big.Float
+		bfp *big.Float
+	)
+	tests := []struct {
+		v    interface{}
+		in   string
+		want string
+	}{
+		{&tm, "2024-05-01T10:20:30Z", "2024-05-01T10:20:30Z"},
+		{&tm, "2024-05-01T10:20:30.5+02:00", "2024-05-01T10:20:30.5+02:00"},
+		{&u, "https://example.com:8443/a?b=c", "https://example.com:8443/a?b=c"},
+		{&up, "s3://bucket/key", "s3://bucket/key"},
+		{&ipn, "10.1.0.0/16", "10.1.0.0/16"},
+		{&pfx, "2001:db8::/32", "2001:db8::/32"},
+		{&ap, "[::1]:8080", "[::1]:8080"},
+		{&ap, "127.0.0.1:53", "127.0.0.1:53"},
+		{&mac, "00:1a:2b:3c:4d:5e", "00:1a:2b:3c:4d:5e"},
+		{&re, `^v\d+$`, `^v\d+$`},
+		{&fm, "0644", "0644"},
+		{&fm, "0o750", "0750"},
+		{&bi, "123456789012345678901234567890", "123456789012345678901234567890"},
+		{&bip, "0x10", "16"},
+		{&bf, "1.25e100", "1.25e+100"},
+		{&bfp, "3.5", "3.5"},
+	}
+	for _, tt := range tests {
+		o := optConv(tt.v)
+		if err := o.Parse(tt.in); err != nil {
+			t.Errorf("%T Parse(%q): %v", tt.v, tt.in, err)
+			continue
+		}
+		got := o.String()
+		if got != tt.want {
+			t.Errorf("%T Parse(%q).String() = %q; want %q", tt.v, tt.in, got, tt.want)
+		}
+		if err := o.Parse(got); err != nil || o.String() != got {
+			t.Errorf("%T: %q does not round-trip (%v)", tt.v, got, err)
+		}
+	}
+}
+
+func TestBuiltinTypesZeroStringEmpty(t *testing.T) {
+	var (
+		tm  time.Time
+		up  *url.URL
+		ipn net.IPNet
+		pfx netip.Prefix
+		ap  netip.AddrPort
+		mac net.HardwareAddr
+		re  *regexp.Regexp
+		bip *big.Int
+	)
+	for _, v := range []interface{}{&tm, &up, &ipn, &pfx, &ap, &mac, &re, &bip} {
+		if s := optConv(v).String(); s != "" {
+			t.Errorf("%T zero value String() = %q; want \"\"", v, s)
+		}
+	}
+}
+
+func TestBuiltinTypesRejectInvalid(t *testing.T) {
+	var (
+		tm  time.Time
+		ipn net.IPNet
+		pfx netip.Prefix
+		ap  netip.AddrPort
+		mac net.HardwareAddr
+		re  *regexp.Regexp
+		fm  os.FileMode
+		bi  big.Int
+		bf  big.Float
+	)
+	tests := []struct {
+		v  interface{}
+		in string
+	}{
+		{&tm, "yesterday"},
+		{&ipn, "10.0.0.1"},
+		{&pfx, "10.0.0.0/33"},
+		{&ap, "localhost"},
+		{&mac, "00:1a:2b"},
+		{&re, "a("},
+		{&fm, "0689"},
+		{&bi, "12ab"},
+		{&bf, "one"},
+	}
+	for _, tt := range tests {
+		if err := optConv(tt.v).Parse(tt.in); err == nil {
+			t.Errorf("%T Parse(%q): expected error", tt.v, tt.in)
+		}
+	}
+}
+
+func TestBigParseErrorKeepsValue(t *testing.T) {
+	bi := big.NewInt(7)
+	bf := big.NewFloat(1.5).SetPrec(200)
+	for _, in := range []string{"12ab", "0x1g", "-"} {
+		optConv(bi).Parse(in)
+		optConv(bf).Parse(in)
+	}
+	if bi.Int64() != 7 || bf.String() != "1.5" || bf.Prec() != 200 {
+		t.Errorf("after failed parses: %v, %v (prec %d); want 7, 1.5 (prec 200)", bi, bf, bf.Prec())
+	}
+	if err := optConv(bf).Parse("0.1"); err != nil || bf.Prec() != 200 {
+		t.Errorf("Parse(0.1): %v, prec %d; want prec 200 kept", err, bf.Prec())
+	}
+}
+
+func TestTimeLayout(t *testing.T) {
+	var tm time.Time
+	o := TimeLayout(&tm, "2006-01-02", time.RFC3339)
+	if err := o.Parse("2024-02-29"); err != nil {
+		t.Fatal(err)
+	}
+	if s := o.String(); s != "2024-02-29" {
+		t.Errorf("String() = %q; want \"2024-02-29\"", s)
+	}
+	if err := o.Parse("2024-02-29T12:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	if tm.Hour() != 12 {
+		t.Errorf("hour = %d; want 12", tm.Hour())
+	}
+	if err := o.Parse("29/02/2024"); err == nil {
+		t.Error("expected error for unmatched layout")
+	}
+}
@@ -0,0 +1,157 @@
+package clip
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Validate checks the options and sub-commands registered on p for mistakes
+// that would otherwise surface as surprising behaviour at parse time:
+//
+//   - a short or long name used by two options of a command, or shared by an
+//     option and a persistent option of one of its ancestors; an option with
+//     the very same short and long names overrides the persistent one, see
+//     [Option.Persistent]
+//   - an option named like the help option, "help-a", "print-config" or the
+//     "--no-" form of a flag
+//   - an option with neither a short nor a long name, and a positional or
+//     sub-command without a name
+//   - MustSet on a flag, which can never be missing
+//   - more than one variadic positional on a command, or one not bound to a
+//     slice, see [Option.Arity]
+//   - two sub-commands of a command with the same name
+//   - a sub-command name that is a prefix of another, as "get" is of
+//     "get-all": an exact name always wins, but every abbreviation of the
+//     shorter one, such as "ge", is ambiguous
+//
+// Every Parse runs the same checks, except the last, so a mistake, even in an
+// option added after an earlier Parse, fails it; call Validate from a test to catch mistakes before
+// shipping.  The returned error lists every problem found, one per line.
+func (p *Parser) Validate() error {
+	return p.validate(true)
+}
+
+// validate implements Validate.  Parse leaves out the prefix check, since
+// such names used to be accepted and still parse.
+func (p *Parser) validate(prefixes bool) error {
+	var errs []error
+	p.walk(func(c *Command) {
+		errs = append(errs, p.validateCommand(c, prefixes)...)
+	})
+	return errors.Join(errs...)
+}
+
+func (p *Parser) validateCommand(c *Command, prefixes bool) []error {
+	var errs []error
+	where := "root command"
+	if c.parent != nil {
+		var names []string
+		for pc := c; pc.parent != nil; pc = pc.parent {
+			names = append([]string{pc.Name}, names...)
+		}
+		where = fmt.Sprintf("command %q", strings.Join(names, " "))
+	}
+	report := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", where, fmt.Sprintf(format, args...)))
+	}
+
+	// Own options come first; the help option is only in the root's once
+	// Parse has run.
+	help := &p.helpOption
+	opts := slices.DeleteFunc(slices.Clone(c.lookupOpts()), func(o *Option) bool { return o == help })
+	own := len(opts) - len(c.inheritedOpts())
+	for i, o := range opts[:own] {
+		if o.shortName == 0 && o.longName == "" {
+			report("option without a name")
+		}
+		if o.mustSet && !o.hasArg {
+			report("MustSet on flag %s", o.displayName())
+		}
+		if o.shortName != 0 && o.shortName == help.shortName {
+			report("option -%c clashes with the help option", o.shortName)
+		}
+		switch o.longName {
+		case "":
+		case help.longName, "help-a", "print-config":
+			report("option --%s clashes with a built-in option", o.longName)
+		}
+		for j, q := range opts[i+1:] {
+			other := "option " + q.displayName()
+			if i+1+j >= own {
+				// Redefining an inherited option outright is an override.
+				if o.shortName == q.shortName && o.longName == q.longName {
+					continue
+				}
+				other = "persistent " + other
+			}
+			if o == &p.versionOption || q == &p.versionOption {
+				other = "the version option"
+			}
+			if o.shortName != 0 && o.shortName == q.shortName {
+				report("option -%c clashes with %s", o.shortName, other)
+			}
+			if o.longName != "" && o.longName == q.longName {
+				report("option --%s clashes with %s", o.longName, other)
+			}
+			if o.longName != "" && (q.negatable() && o.longName == "no-"+q.longName ||
+				o.negatable() && q.longName == "no-"+o.longName) {
+				report("options --%s and --%s clash with each other's --no- form", o.longName, q.longName)
+			}
+		}
+	}
+	variadic := ""
+	for _, o := range c.positionals {
+		if o.longName == "" {
+			report("positional without a name")
+		}
+		if o.variadic() {
+			if v, _ := unwrapValue(o.v); !appends(v) {
+				report("variadic positional %s is not bound to a slice", o.longName)
+			}
+			if variadic != "" {
+				report("positionals %s and %s are both variadic", variadic, o.longName)
+			}
+			variadic = o.longName
+		}
+	}
+	for i, sc := range c.subcmds {
+		if sc.Name == "" {
+			report("sub-command without a name")
+			continue
+		}
+		for _, sc2 := range c.subcmds[i+1:] {
+			if sc.Name == sc2.Name {
+				report("sub-command %q registered more than once", sc.Name)
+				continue
+			}
+			short, long := sc.Name, sc2.Name
+			if len(short) > len(long) {
+				short, long = long, short
+			}
+			if prefixes && strings.HasPrefix(long, short) {
+				report("sub-command %q is a prefix of %q, so its abbreviations are ambiguous", short, long)
+			}
+		}
+	}
+	return errs
+}
+
+// appends reports whether v keeps every value it parses, as a slice does.
+func appends(v IOption) bool {
+	_, ok := v.(interface{ appends() })
+	return ok
+}
+
+// displayName returns o's name as a user would type it, e.g. "-c/--config".
+func (o *Option) displayName() string {
+	var names []string
+	if o.shortName != 0 {
+		names = append(names, "-"+string(o.shortName))
+	}
+	if o.longName != "" {
+		names = append(names, "--"+o.longName)
+	}
+	return strings.Join(names, "/")
+}
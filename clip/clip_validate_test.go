@@ -0,0 +1,132 @@
+package clip
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	var b bool
+	var s string
+	tests := []struct {
+		name  string
+		setup func(p *Parser)
+		want  string // "" means valid
+	}{
+		{"clean", func(p *Parser) {
+			p.FlagOption(&b, 'v', "verbose", "").Persistent()
+			p.SubCommand("gcloud", "", "").FlagOption(&b, 'v', "verbose", "")
+			p.SubCommand("gcompute", "", "")
+			p.Version("1.0")
+		}, ""},
+		{"short twice", func(p *Parser) {
+			p.ArgOption(&s, 'c', "config", "F", "")
+			p.FlagOption(&b, 'c', "color", "")
+		}, "root command: option -c clashes with option -c/--color"},
+		{"long twice", func(p *Parser) {
+			p.ArgOption(&s, 0, "out", "F", "")
+			p.ArgOption(&s, 'o', "out", "F", "")
+		}, "root command: option --out clashes with option -o/--out"},
+		{"persistent parent", func(p *Parser) {
+			p.FlagOption(&b, 'q', "quiet", "").Persistent()
+			p.SubCommand("db", "", "").SubCommand("migrate", "", "").FlagOption(&b, 'q', "quick", "")
+		}, `command "db migrate": option -q clashes with persistent option -q/--quiet`},
+		{"help", func(p *Parser) {
+			p.SubCommand("ls", "", "").FlagOption(&b, 'h', "human", "")
+		}, `command "ls": option -h clashes with the help option`},
+		{"help-a", func(p *Parser) {
+			p.FlagOption(&b, 0, "help-a", "")
+		}, "root command: option --help-a clashes with a built-in option"},
+		{"version", func(p *Parser) {
+			p.FlagOption(&b, 'V', "version", "")
+			p.Version("1.0")
+		}, "root command: option --version clashes with the version option"},
+		{"negated", func(p *Parser) {
+			p.FlagOption(&b, 0, "cache", "")
+			p.FlagOption(&b, 0, "no-cache", "")
+		}, "root command: options --cache and --no-cache clash with each other's --no- form"},
+		{"unnamed", func(p *Parser) {
+			p.FlagOption(&b, 0, "", "")
+			p.SubCommand("", "", "")
+		}, "root command: option without a name\nroot command: sub-command without a name"},
+		{"unnamed positional", func(p *Parser) {
+			p.Positional(&s, "", "")
+		}, "root command: positional without a name"},
+		{"two variadics", func(p *Parser) {
+			var l []string
+			p.Positional(&l, "SRC", "").Arity(ArityOneOrMore)
+			p.Positional(&s, "DST", "")
+			p.Positional(&l, "MORE", "").Arity(ArityAny)
+		}, "root command: positionals SRC and MORE are both variadic"},
+		{"variadic string", func(p *Parser) {
+			p.Positional(&s, "FILE", "").Arity(ArityAny)
+		}, "root command: variadic positional FILE is not bound to a slice"},
+		{"MustSet flag", func(p *Parser) {
+			p.FlagOption(&b, 'f', "force", "").MustSet()
+		}, "root command: MustSet on flag -f/--force"},
+		{"sub-command twice", func(p *Parser) {
+			p.SubCommand("serve", "", "")
+			p.SubCommand("serve", "", "")
+		}, `root command: sub-command "serve" registered more than once`},
+	}
+	for _, tt := range tests {
+		p := New()
+		tt.setup(p)
+		err := p.Validate()
+		if got := errString(err); got != tt.want {
+			t.Errorf("%s: Validate() = %q; want %q", tt.name, got, tt.want)
+		}
+		if _, perr := p.Parse([]string{"prog"}); tt.want != "" && errString(perr) != tt.want {
+			t.Errorf("%s: Parse err = %v; want the Validate error", tt.name, perr)
+		}
+		p.Close()
+	}
+}
+
+func TestValidateSubCommandPrefix(t *testing.T) {
+	p := New()
+	defer p.Close()
+	p.SubCommand("get-all", "", "")
+	p.SubCommand("get", "", "")
+	want := `root command: sub-command "get" is a prefix of "get-all", so its abbreviations are ambiguous`
+	if err := p.Validate(); errString(err) != want {
+		t.Errorf("Validate() = %v; want %q", err, want)
+	}
+	// Parse does not insist: the exact name still selects the command.
+	if cmd, err := p.Parse([]string{"prog", "get"}); err != nil || cmd.Name != "get" {
+		t.Errorf("Parse = %v, %v; want get", cmd, err)
+	}
+}
+
+func TestValidateAfterParse(t *testing.T) {
+	p := New()
+	defer p.Close()
+	var b bool
+	p.FlagOption(&b, 'v', "verbose", "")
+	if _, err := p.Parse([]string{"prog"}); err != nil {
+		t.Fatal(err)
+	}
+	// The help option is now among the root's options.
+	if err := p.Validate(); err != nil {
+		t.Errorf("Validate after Parse: %v", err)
+	}
+
+	// Options and sub-commands added after a Parse are checked by the next.
+	var c bool
+	p.FlagOption(&c, 'v', "color", "")
+	if _, err := p.Parse([]string{"prog"}); err == nil {
+		t.Error("Parse accepted a second -v added after the first Parse")
+	}
+	p.Command.opts = p.Command.opts[:len(p.Command.opts)-1]
+	p.SubCommand("", "", "")
+	if _, err := p.Parse([]string{"prog"}); err == nil {
+		t.Error("Parse accepted an unnamed sub-command added after the first Parse")
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return strings.TrimSpace(err.Error())
+}
@@ -0,0 +1,203 @@
+package clip
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Value is a generic [IOption] that stores parsed values of type T in a
+// caller-owned variable.  It lets any type be used as an option or positional
+// without a hand-written wrapper in clip_type.go; type mismatches are caught
+// by the compiler instead of by a panic at setup time.
+type Value[T any] struct {
+	p      *T
+	parse  func(string) (T, error)
+	format func(T) string
+}
+
+// NewValue returns a Value that parses with parse and stores into p.
+//
+// If parse is nil, T must be one of the types accepted by
+// [Command.ArgOption] and the built-in conversion is used; NewValue panics
+// immediately for any other T.
+func NewValue[T any](p *T, parse func(string) (T, error)) *Value[T] {
+	v := &Value[T]{p: p, parse: parse}
+	if parse == nil {
+		optConv(p) // panics now, not on first use, if T is unsupported
+		v.parse = builtinParse[T]
+		v.format = builtinFormat[T]
+	}
+	return v
+}
+
+// Format sets the function used by String to render the current value, e.g.
+// for the "(default: …)" text in help.  Without it, fmt.Sprint is used.
+func (v *Value[T]) Format(f func(T) string) *Value[T] {
+	v.format = f
+	return v
+}
+
+// Get returns the current value.
+func (v *Value[T]) Get() T { return *v.p }
+
+func (v *Value[T]) String() string {
+	if v.p == nil {
+		return ""
+	}
+	if v.format != nil {
+		return v.format(*v.p)
+	}
+	return fmt.Sprint(*v.p)
+}
+
+// typeName names T in a schema; see Parser.Schema.
+func (v *Value[T]) typeName() string { return reflect.TypeFor[T]().String() }
+
+func (v *Value[T]) Parse(s string) error {
+	x, err := v.parse(s)
+	if err == nil {
+		*v.p = x
+	}
+	return err
+}
+
+// snapshotter is implemented by values whose state cannot be saved by
+// copying what their pointer points to, e.g. because they hold a pointer to
+// the caller's variable.
+type snapshotter interface {
+	snapshot() (restore func())
+}
+
+// snapshot returns a function that restores v to its current value.  Values
+// that do not implement snapshotter are restored by a shallow copy of the
+// variable their pointer refers to, which suits the built-in types and most
+// custom ones.
+func snapshot(v IOption) (restore func()) {
+	if s, ok := v.(snapshotter); ok {
+		return s.snapshot()
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return func() {}
+	}
+	saved := reflect.New(rv.Elem().Type()).Elem()
+	saved.Set(rv.Elem())
+	return func() { rv.Elem().Set(saved) }
+}
+
+func (v *Value[T]) snapshot() func() {
+	x := *v.p
+	return func() { *v.p = x }
+}
+
+// builtinParse parses s with the clipXxx wrapper that optConv selects for T.
+func builtinParse[T any](s string) (T, error) {
+	var x T
+	err := optConv(&x).Parse(s)
+	return x, err
+}
+
+func builtinFormat[T any](x T) string {
+	return optConv(&x).String()
+}
+
+// Arg registers an argument option on c whose value is produced by parse and
+// stored in *p.  It is the type-safe counterpart of [Command.ArgOption];
+// pass &parser.Command to register on a [Parser]'s root.  A nil parse falls
+// back to the built-in conversion for T, as described for [NewValue].
+func Arg[T any](c *Command, p *T, shortName byte, longName, argName, desc string, parse func(string) (T, error)) *Option {
+	return c.ArgOptionCustom(NewValue(p, parse), shortName, longName, argName, desc)
+}
+
+// Pos registers a positional argument on c whose value is produced by parse
+// and stored in *p.  It is the type-safe counterpart of [Command.Positional].
+func Pos[T any](c *Command, p *T, name, desc string, parse func(string) (T, error)) *Option {
+	return c.PositionalCustom(NewValue(p, parse), name, desc)
+}
+
+// listValue is an [IOption] that appends each parsed value to a slice.  The
+// first Parse replaces whatever the slice held before, so a default list is
+// overridden rather than extended by command-line values.
+type listValue[T any] struct {
+	p      *[]T
+	parse  func(string) (T, error)
+	format func(T) string
+	set    bool
+}
+
+// NewList returns an [IOption] that appends to *p, for use with repeatable
+// options and variadic positionals (see [Option.Arity]).  A nil parse uses
+// the built-in conversion for T, as described for [NewValue].
+func NewList[T any](p *[]T, parse func(string) (T, error)) IOption {
+	l := &listValue[T]{p: p, parse: parse, format: func(x T) string { return fmt.Sprint(x) }}
+	if parse == nil {
+		var x T
+		optConv(&x) // panics now if T is unsupported
+		l.parse = builtinParse[T]
+		l.format = builtinFormat[T]
+	}
+	return l
+}
+
+func (l *listValue[T]) String() string {
+	ss := l.strings()
+	return strings.Join(ss, listSep(ss))
+}
+
+func (l *listValue[T]) strings() []string {
+	ss := make([]string, len(*l.p))
+	for i, x := range *l.p {
+		ss[i] = l.format(x)
+	}
+	return ss
+}
+
+// sep returns the separator String joins the elements with.
+func (l *listValue[T]) sep() string { return listSep(l.strings()) }
+
+// listSep returns the first of ",", ";", "|", space, tab and newline that no
+// element of ss contains, so the joined list can be split again.
+func listSep(ss []string) string {
+	for _, r := range ",;| \t\n" {
+		if !slices.ContainsFunc(ss, func(s string) bool { return strings.ContainsRune(s, r) }) {
+			return string(r)
+		}
+	}
+	return ","
+}
+
+// valueSep returns the separator v's String joins a list with, or "" if v is
+// not a list.
+func valueSep(v IOption) string {
+	v, _ = unwrapValue(v)
+	if l, ok := v.(interface{ sep() string }); ok {
+		return l.sep()
+	}
+	return ""
+}
+
+// The saved slice is safe to keep: the first Parse starts a new one.
+func (l *listValue[T]) snapshot() func() {
+	x := *l.p
+	return func() { *l.p, l.set = x, false }
+}
+
+// appends marks the values that keep every token they parse, as a variadic
+// positional needs; see Parser.Validate.
+func (l *listValue[T]) appends() {}
+
+func (l *listValue[T]) typeName() string { return reflect.TypeFor[[]T]().String() }
+
+func (l *listValue[T]) Parse(s string) error {
+	x, err := l.parse(s)
+	if err != nil {
+		return err
+	}
+	if !l.set {
+		*l.p, l.set = nil, true
+	}
+	*l.p = append(*l.p, x)
+	return nil
+}
@@ -0,0 +1,120 @@
+package clip
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type level int
+
+func parseLevel(s string) (level, error) {
+	switch strings.ToLower(s) {
+	case "low":
+		return 1, nil
+	case "high":
+		return 2, nil
+	}
+	return 0, errors.New("level must be low or high")
+}
+
+func TestArgGeneric(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var lv level
+	Arg(&p.Command, &lv, 'l', "level", "LEVEL", "", parseLevel)
+	if _, err := p.Parse([]string{"prog", "--level", "HIGH"}); err != nil {
+		t.Fatal(err)
+	}
+	if lv != 2 {
+		t.Errorf("lv = %d; want 2", lv)
+	}
+}
+
+func TestArgGenericParseError(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var lv level
+	Arg(&p.Command, &lv, 'l', "level", "LEVEL", "", parseLevel)
+	if _, err := p.Parse([]string{"prog", "-l", "medium"}); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestArgGenericBuiltinFallback(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	port := 80
+	o := Arg(&p.Command, &port, 'p', "port", "PORT", "", nil)
+	if s := o.v.String(); s != "80" {
+		t.Errorf("String() = %q; want \"80\"", s)
+	}
+	if _, err := p.Parse([]string{"prog", "-p", "8080"}); err != nil {
+		t.Fatal(err)
+	}
+	if port != 8080 {
+		t.Errorf("port = %d; want 8080", port)
+	}
+}
+
+func TestNewValueUnsupportedPanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic")
+		}
+	}()
+	var lv level
+	NewValue(&lv, nil)
+}
+
+func TestPosGeneric(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var lv level
+	Pos(&p.Command, &lv, "level", "", parseLevel)
+	if _, err := p.Parse([]string{"prog", "low"}); err != nil {
+		t.Fatal(err)
+	}
+	if lv != 1 {
+		t.Errorf("lv = %d; want 1", lv)
+	}
+}
+
+func TestValueFormat(t *testing.T) {
+	lv := level(2)
+	v := NewValue(&lv, parseLevel).Format(func(l level) string {
+		return [...]string{"", "low", "high"}[l]
+	})
+	if s := v.String(); s != "high" {
+		t.Errorf("String() = %q; want \"high\"", s)
+	}
+}
+
+func TestArgOptionAcceptsIOption(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var lv level
+	p.ArgOption(NewValue(&lv, parseLevel), 0, "level", "LEVEL", "")
+	if _, err := p.Parse([]string{"prog", "--level=low"}); err != nil {
+		t.Fatal(err)
+	}
+	if lv != 1 {
+		t.Errorf("lv = %d; want 1", lv)
+	}
+}
+
+func TestArgOptionIntRejectsGarbage(t *testing.T) {
+	p := New()
+	defer p.Close()
+
+	var n int
+	p.ArgOption(&n, 'n', "num", "N", "")
+	if _, err := p.Parse([]string{"prog", "-n", "abc"}); err == nil {
+		t.Error("expected error for non-numeric int")
+	}
+}
@@ -0,0 +1,86 @@
+package clip
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strings"
+)
+
+// ErrVersion is returned by [Parser.Parse] when the user passes --version.
+// The version has already been written to the parser's output; callers
+// should exit with status 0.
+var ErrVersion = errors.New("version requested")
+
+// Version adds a --version option to the root command that prints the
+// program name and v, and makes Parse return [ErrVersion].  Like the help
+// option, it is recognised after any sub-command too.
+//
+// With an empty v, the version is read from the build information the Go
+// toolchain embeds in the binary: the main module's version, followed by the
+// VCS revision, "dirty" if the working tree had uncommitted changes, and the
+// time the revision was committed, as in
+//
+//	tool v1.4.0 (rev 1a2b3c4d5e6f, dirty, committed 2026-10-01T12:00:00Z)
+//
+// The toolchain does not record the build time.
+//
+// A binary built with "go build" in a checkout has the version "(devel)".
+// Returns p so calls can be chained.
+func (p *Parser) Version(v string) *Parser {
+	p.version = v
+	if !slices.Contains(p.Command.opts, &p.versionOption) {
+		p.Command.opts = append(p.Command.opts, &p.versionOption)
+	}
+	return p
+}
+
+// versionString returns the version printed for --version.
+func (p *Parser) versionString() string {
+	if p.version != "" {
+		return p.version
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	return buildVersion(bi)
+}
+
+// buildVersion formats the version recorded in bi.
+func buildVersion(bi *debug.BuildInfo) string {
+	v := bi.Main.Version
+	if v == "" {
+		v = "(devel)"
+	}
+	var extra []string
+	var rev, modified, at string
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		case "vcs.time":
+			at = s.Value
+		}
+	}
+	if rev != "" {
+		extra = append(extra, "rev "+rev[:min(len(rev), 12)])
+	}
+	if modified == "true" {
+		extra = append(extra, "dirty")
+	}
+	if at != "" {
+		extra = append(extra, "committed "+at)
+	}
+	if len(extra) > 0 {
+		v += " (" + strings.Join(extra, ", ") + ")"
+	}
+	return v
+}
+
+func (p *Parser) printVersion() {
+	fmt.Fprintf(p.output(), "%s %s\n", p.shownName(), p.versionString())
+}
@@ -0,0 +1,63 @@
+package clip
+
+import (
+	"bytes"
+	"errors"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	var out bytes.Buffer
+	p := New().SetOutput(&out).Version("1.2.3").Version("1.2.4")
+	defer p.Close()
+	p.Name = "tool"
+	p.SubCommand("serve", "", "")
+
+	if _, err := p.Parse([]string{"tool", "--version"}); !errors.Is(err, ErrVersion) {
+		t.Fatalf("err = %v; want ErrVersion", err)
+	}
+	if out.String() != "tool 1.2.4\n" {
+		t.Errorf("output = %q", out.String())
+	}
+	out.Reset()
+	if _, err := p.Parse([]string{"tool", "serve", "--version"}); !errors.Is(err, ErrVersion) || out.String() != "tool 1.2.4\n" {
+		t.Errorf("after a sub-command: err = %v, output %q; want ErrVersion", err, out.String())
+	}
+
+	out.Reset()
+	p.HelpCommand(nil, false)
+	if strings.Count(out.String(), "--version") != 1 {
+		t.Errorf("help should list --version once:\n%s", out.String())
+	}
+}
+
+func TestVersionAuto(t *testing.T) {
+	var out bytes.Buffer
+	p := New().SetOutput(&out).Version("")
+	p.Name = "tool"
+	if code := p.Execute([]string{"tool", "--version"}); code != 0 {
+		t.Errorf("Execute = %d; want 0", code)
+	}
+	if !strings.HasPrefix(out.String(), "tool ") || len(out.String()) <= len("tool \n") {
+		t.Errorf("output = %q", out.String())
+	}
+}
+
+func TestBuildVersion(t *testing.T) {
+	bi := &debug.BuildInfo{
+		Main: debug.Module{Version: "v1.4.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "1a2b3c4d5e6f7a8b9c0d"},
+			{Key: "vcs.time", Value: "2026-10-01T12:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	if got, want := buildVersion(bi), "v1.4.0 (rev 1a2b3c4d5e6f, dirty, committed 2026-10-01T12:00:00Z)"; got != want {
+		t.Errorf("buildVersion = %q; want %q", got, want)
+	}
+	if got := buildVersion(&debug.BuildInfo{}); got != "(devel)" {
+		t.Errorf("buildVersion of empty info = %q", got)
+	}
+}
@@ -0,0 +1,145 @@
+// Package cliptest runs a [clip.Parser] in isolation for tests.
+//
+// [Parse] and [Run] feed an argument vector to a parser and capture
+// everything it produces — the matched command or error, help and warnings,
+// and log lines written with [clip.Command.Logf] — without touching os.Stdout
+// or sleeping for the logging goroutine:
+//
+//	p := newParser()
+//	res := cliptest.Run(t, p, "prog", "serve", "--port", "80")
+//	if res.Err != nil {
+//		t.Fatal(res.Err)
+//	}
+//	cliptest.Golden(t, "serve.log", res.Log)
+//
+// [Golden] compares output with a file under testdata; run the tests with
+// -cliptest.update, or with CLIPTEST_UPDATE=1 in the environment, to rewrite
+// the files.  A [FakeClock] makes log timestamps reproducible.
+package cliptest
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alnxdk/xkit/clip"
+)
+
+var update = flag.Bool("cliptest.update", false, "rewrite golden files used by cliptest.Golden")
+
+// Result is what one call to [Parse] or [Run] produced.
+type Result struct {
+	Command *clip.Command // matched command; nil if Parse failed
+	Err     error         // from Parse, or from Command.Run
+	Output  string        // help, warnings and --print-config output
+	Log     string        // log lines, unless a log file is configured
+}
+
+// Parse parses argv, whose first element is the program name, with p.  It
+// captures p's output and log output, then closes p so that every log line
+// has been written before it returns.  Afterwards both outputs are set back
+// to the writers p had before.
+func Parse(t testing.TB, p *clip.Parser, argv ...string) *Result {
+	t.Helper()
+	return run(p, argv, false)
+}
+
+// Run is like [Parse], but also runs the matched command if parsing
+// succeeds; Err is then the error returned by [clip.Command.Run].
+func Run(t testing.TB, p *clip.Parser, argv ...string) *Result {
+	t.Helper()
+	return run(p, argv, true)
+}
+
+func run(p *clip.Parser, argv []string, exec bool) *Result {
+	var out, logs bytes.Buffer
+	prevOut, prevLog := p.Output(), p.LogOutput()
+	p.SetOutput(&out).SetLogOutput(&logs)
+	defer func() { p.SetOutput(prevOut).SetLogOutput(prevLog) }()
+
+	if len(argv) == 0 {
+		argv = []string{"prog"} // Parse would fall back to os.Args
+	}
+	r := &Result{}
+	r.Command, r.Err = p.Parse(argv)
+	if r.Err == nil && exec {
+		r.Err = r.Command.Run()
+	}
+	// Run closes the log only for the root; Close is a no-op when done.
+	p.Close()
+	r.Output, r.Log = out.String(), logs.String()
+	return r
+}
+
+// Help returns the help text of the command selected by the sub-command
+// names in path, as printed for --help.
+func Help(t testing.TB, p *clip.Parser, path ...string) string {
+	t.Helper()
+	r := Parse(t, p, append(append([]string{"prog"}, path...), "--help")...)
+	if !errors.Is(r.Err, clip.ErrHelp) {
+		t.Fatalf("cliptest.Help %q: %v", path, r.Err)
+	}
+	return r.Output
+}
+
+// Golden compares got with the contents of testdata/name and reports a
+// mismatch as a test error.  With -cliptest.update, or CLIPTEST_UPDATE set
+// to a non-empty value, it writes got to the file instead.
+func Golden(t testing.TB, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update || os.Getenv("CLIPTEST_UPDATE") != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -cliptest.update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch:\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
+
+// FakeClock is a clock for [clip.Parser.SetClock] that only moves when
+// told to.  It is safe for concurrent use.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock returns a FakeClock set to t.
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{now: t}
+}
+
+// Now returns the clock's current time; pass it to SetClock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to t.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
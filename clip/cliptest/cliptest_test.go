@@ -0,0 +1,95 @@
+package cliptest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alnxdk/xkit/clip"
+)
+
+func newParser(port *int) *clip.Parser {
+	p := clip.New()
+	p.ProgDescription("Test server")
+	serve := p.SubCommand("serve", "Start serving", "")
+	serve.ArgOption(port, 'p', "port", "PORT", "Port to listen on")
+	serve.SetRuns(func(c *clip.Command) error {
+		c.Logf("listening on %d", *port)
+		if *port == 0 {
+			return errors.New("no port")
+		}
+		c.Logf("done")
+		return nil
+	}, nil, nil)
+	return p
+}
+
+func TestRunCapturesLog(t *testing.T) {
+	port := 80
+	p := newParser(&port)
+	clock := NewFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local))
+	p.SetClock(clock.Now)
+
+	r := Run(t, p, "prog", "serve", "-p", "8080")
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	if r.Command == nil || r.Command.Name != "serve" {
+		t.Errorf("Command = %v; want serve", r.Command)
+	}
+	want := "2024/05/01 12:00:00 listening on 8080\n2024/05/01 12:00:00 done\n"
+	if r.Log != want {
+		t.Errorf("Log = %q; want %q", r.Log, want)
+	}
+
+	clock.Advance(90 * time.Second)
+	r = Run(t, p, "prog", "serve", "-p", "0")
+	if r.Err == nil || r.Err.Error() != "no port" {
+		t.Errorf("Err = %v; want no port", r.Err)
+	}
+	if !strings.HasPrefix(r.Log, "2024/05/01 12:01:30 listening on 0\n") {
+		t.Errorf("Log = %q", r.Log)
+	}
+}
+
+func TestRestoresOutputs(t *testing.T) {
+	port := 80
+	var out, logs strings.Builder
+	p := newParser(&port).SetOutput(&out).SetLogOutput(&logs)
+	Parse(t, p, "prog", "--help")
+	if p.Output() != &out || p.LogOutput() != &logs {
+		t.Error("the parser's own writers were not restored")
+	}
+	if out.Len() != 0 {
+		t.Errorf("help leaked to the parser's writer: %q", out.String())
+	}
+}
+
+func TestParseError(t *testing.T) {
+	port := 80
+	r := Parse(t, newParser(&port), "prog", "serve", "--bogus")
+	if r.Err == nil || r.Command != nil {
+		t.Errorf("Err = %v, Command = %v; want error and nil", r.Err, r.Command)
+	}
+}
+
+func TestHelpGolden(t *testing.T) {
+	port := 80
+	p := newParser(&port)
+	Golden(t, "root.help", Help(t, p))
+	Golden(t, "serve.help", Help(t, p, "serve"))
+}
+
+func TestFakeClock(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(t0)
+	c.Advance(time.Hour)
+	if got := c.Now(); !got.Equal(t0.Add(time.Hour)) {
+		t.Errorf("Now() = %v after Advance", got)
+	}
+	c.Set(t0)
+	if got := c.Now(); !got.Equal(t0) {
+		t.Errorf("Now() = %v after Set", got)
+	}
+}